@@ -0,0 +1,64 @@
+package clock
+
+import (
+	"time"
+)
+
+// Timer represents a single event timer.
+type Timer interface {
+	// C returns the channel on which the time is delivered.
+	C() <-chan time.Time
+
+	// Stop prevents the timer from firing, it reports whether the timer was active.
+	Stop() bool
+
+	// Reset changes the timer to expire after duration, it reports whether the timer was active.
+	Reset(d time.Duration) bool
+}
+
+// Clock represents a source of current time and timers.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTimer returns a new timer sends the current time on its channel after duration.
+	NewTimer(d time.Duration) Timer
+
+	// After waits for the duration to elapse and then sends the current time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// timer represents a timer of system time.
+type timer struct {
+	*time.Timer
+}
+
+// C implements Timer interface.
+func (t *timer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+// clock represents the clock of system time.
+type clock struct{}
+
+// Now implements Clock interface.
+func (clock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer implements Clock interface.
+func (clock) NewTimer(d time.Duration) Timer {
+	return &timer{
+		Timer: time.NewTimer(d),
+	}
+}
+
+// After implements Clock interface.
+func (clock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+var (
+	// Default represents the clock of system time.
+	Default Clock = clock{}
+)
@@ -0,0 +1,6 @@
+/*
+
+Package clock providers an abstraction of time source for the components depend on current time and timers.
+
+*/
+package clock
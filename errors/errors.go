@@ -35,6 +35,11 @@ func (e *error2) Error() string {
 	return TreeMessage(e)
 }
 
+// Unwrap returns the inner error.
+func (e *error2) Unwrap() error {
+	return e.Inner
+}
+
 func locate(depth int) *location {
 	_, file, line, _ := runtime.Caller(depth + 1)
 	return &location{
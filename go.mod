@@ -2,7 +2,6 @@ module github.com/wayn3h0/gop
 
 require (
 	github.com/go-sql-driver/mysql v1.4.1
-	github.com/gorhill/cronexpr v0.0.0-20180427100037-88b0669f7d75
 	github.com/julienschmidt/httprouter v1.2.0
 	github.com/lib/pq v1.0.0
 	github.com/mattn/go-sqlite3 v1.10.0
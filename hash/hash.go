@@ -1,7 +1,7 @@
 package hash
 
 import (
-	"bytes"
+	"crypto/hmac"
 	"hash"
 
 	"github.com/wayn3h0/gop/errors"
@@ -25,11 +25,12 @@ func Compute(hash hash.Hash, data []byte, more ...[]byte) ([]byte, error) {
 }
 
 // Verify reports whether the value equals to the hash value of given data.
+// The values are compared in constant time.
 func Verify(hash hash.Hash, value []byte, data []byte, more ...[]byte) (bool, error) {
 	val, err := Compute(hash, data, more...)
 	if err != nil {
 		return false, err
 	}
 
-	return bytes.Equal(val, value), nil
+	return hmac.Equal(val, value), nil
 }
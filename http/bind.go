@@ -0,0 +1,254 @@
+package http
+
+import (
+	"encoding"
+	"mime"
+	"mime/multipart"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wayn3h0/gop/errors"
+	"github.com/wayn3h0/gop/uuid"
+)
+
+// DefaultMaxMemory is the max memory of multipart form, the rest of files are stored on disk.
+const DefaultMaxMemory = 32 << 20
+
+// sources represents the tags of binding sources in priority order.
+var sources = []string{"path", "query", "header", "form"}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	uuidType            = reflect.TypeOf(uuid.UUID{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// fieldName returns the name of field in its source, the json name or the name of struct field.
+func fieldName(f reflect.StructField) string {
+	for _, source := range append(sources, "json", "xml") {
+		if name := strings.Split(f.Tag.Get(source), ",")[0]; len(name) > 0 && name != "-" {
+			return name
+		}
+	}
+
+	return f.Name
+}
+
+// isText reports whether the value can be set from text by encoding.TextUnmarshaler.
+func isText(v reflect.Value) bool {
+	return v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType)
+}
+
+// setValue sets the value from string.
+func setValue(v reflect.Value, str string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setValue(v.Elem(), str)
+	}
+	if isText(v) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str))
+	}
+
+	switch {
+	case v.Type() == uuidType:
+		u, err := uuid.Parse(str)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(u))
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(str)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(str)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64:
+		n, err := strconv.ParseInt(str, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uint64:
+		n, err := strconv.ParseUint(str, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64:
+		n, err := strconv.ParseFloat(str, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return errors.Newf("type %s is not supported", v.Type())
+	}
+
+	return nil
+}
+
+// setValues sets the value from strings, the slice gets all values and others get the first one.
+func setValues(v reflect.Value, values []string) error {
+	if v.Kind() == reflect.Slice && v.Type() != reflect.TypeOf([]byte(nil)) && !isText(v) {
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, str := range values {
+			err := setValue(slice.Index(i), str)
+			if err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+
+	return setValue(v, values[0])
+}
+
+// binder represents a binder fills the struct from request.
+type binder struct {
+	Request Request
+	Form    *multipart.Form // nil if the request is not multipart
+}
+
+// lookup returns the values of field from source.
+func (b *binder) lookup(source, name string) []string {
+	req := b.Request.Request
+	switch source {
+	case "path":
+		if v := ParamString(req, name); len(v) > 0 {
+			return []string{v}
+		}
+	case "query":
+		return req.URL.Query()[name]
+	case "header":
+		return req.Header.Values(name)
+	case "form":
+		if values := req.PostForm[name]; len(values) > 0 {
+			return values
+		}
+		if b.Form != nil {
+			return b.Form.Value[name]
+		}
+	}
+
+	return nil
+}
+
+// bind fills the fields of struct by tags.
+func (b *binder) bind(v reflect.Value, errs *FieldErrors) {
+	t := v.Type()
+fields:
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fv := v.Field(i)
+		if len(f.PkgPath) > 0 && !f.Anonymous { // unexported
+			continue
+		}
+
+		// embedded struct
+		if f.Anonymous && fv.Kind() == reflect.Struct {
+			b.bind(fv, errs)
+			continue
+		}
+
+		for _, source := range sources {
+			name := strings.Split(f.Tag.Get(source), ",")[0]
+			if len(name) == 0 || name == "-" {
+				continue
+			}
+
+			// uploaded files
+			if source == "form" && b.Form != nil && len(b.Form.File[name]) > 0 {
+				files := b.Form.File[name]
+				switch fv.Type() {
+				case fileHeaderType:
+					fv.Set(reflect.ValueOf(files[0]))
+					continue fields
+				case reflect.SliceOf(fileHeaderType):
+					fv.Set(reflect.ValueOf(files))
+					continue fields
+				}
+			}
+
+			values := b.lookup(source, name)
+			if len(values) == 0 {
+				continue
+			}
+			err := setValues(fv, values)
+			if err != nil {
+				*errs = append(*errs, &FieldError{
+					Field:   name,
+					Rule:    "type",
+					Message: "could not be parsed as " + fv.Type().String(),
+				})
+			}
+			break
+		}
+	}
+}
+
+// Bind fills the struct pointed by dst from the request and validates it by Validate func.
+// The body is decoded as JSON or XML by Content-Type, then the fields are set from the sources by tags in priority order:
+//
+//	path:"name"     route parameter
+//	query:"name"    query string
+//	header:"name"   header
+//	form:"name"     urlencoded or multipart form, the *multipart.FileHeader and []*multipart.FileHeader fields get the files
+//
+// The field can be string, bool, number, time.Duration, uuid.UUID, encoding.TextUnmarshaler (e.g. time.Time), pointers and slices of them.
+// It returns FieldErrors if any field cannot be parsed or is invalid.
+func (r Request) Bind(dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("http: destination of binding must be a pointer to struct")
+	}
+
+	b := &binder{
+		Request: r,
+	}
+	contentType, _, _ := mime.ParseMediaType(r.Request.Header.Get("Content-Type"))
+	switch {
+	case contentType == "application/json" || strings.HasSuffix(contentType, "+json"):
+		err := r.ReadJSON(dst)
+		if err != nil {
+			return err
+		}
+	case contentType == "application/xml" || contentType == "text/xml" || strings.HasSuffix(contentType, "+xml"):
+		err := r.ReadXML(dst)
+		if err != nil {
+			return err
+		}
+	case contentType == "multipart/form-data":
+		err := r.Request.ParseMultipartForm(DefaultMaxMemory)
+		if err != nil {
+			return errors.Wrap(err, "http: could not parse multipart form from request body")
+		}
+		b.Form = r.Request.MultipartForm
+	case contentType == "application/x-www-form-urlencoded":
+		err := r.Request.ParseForm()
+		if err != nil {
+			return errors.Wrap(err, "http: could not parse form from request body")
+		}
+	}
+
+	var errs FieldErrors
+	b.bind(v.Elem(), &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return Validate(dst)
+}
@@ -0,0 +1,158 @@
+package http
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	testing2 "github.com/wayn3h0/gop/testing"
+	"github.com/wayn3h0/gop/uuid"
+)
+
+type Paging struct {
+	Page int `query:"page" validate:"min=1"`
+	Size int `query:"size" validate:"max=100"`
+}
+
+type order struct {
+	Paging
+	ID       uuid.UUID     `path:"id"`
+	Token    string        `header:"X-Token" validate:"required"`
+	Tags     []string      `query:"tag" validate:"max=2,regexp=^[a-z]+$"`
+	Since    *time.Time    `query:"since"`
+	Timeout  time.Duration `query:"timeout"`
+	Name     string        `json:"name" validate:"required,min=2"`
+	Country  string        `json:"country" validate:"country"`
+	Currency string        `json:"currency" validate:"currency"`
+	Status   string        `json:"status" validate:"oneof=open closed"`
+	Ref      string        `json:"ref" validate:"uuid"`
+}
+
+func TestBind(t *testing.T) {
+	id := "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	body := `{"name":"order","country":"CN","currency":"CNY","status":"open","ref":"` + id + `"}`
+	req := httptest.NewRequest("POST", "/orders/"+id+"?page=2&size=10&tag=a&tag=b&since=2018-10-01T00:00:00Z&timeout=1m", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("X-Token", "token")
+	req = WithParams(req, Param{Key: "id", Value: id})
+
+	var o order
+	err := NewRequest(req).Bind(&o)
+	testing2.AssertEqual(t, err, nil)
+	testing2.ExpectEqual(t, o.ID.String(), id)
+	testing2.ExpectEqual(t, o.Token, "token")
+	testing2.ExpectEqual(t, o.Page, 2)
+	testing2.ExpectEqual(t, o.Size, 10)
+	testing2.ExpectEqual(t, o.Tags, []string{"a", "b"})
+	testing2.ExpectEqual(t, o.Since.Equal(time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC)), true)
+	testing2.ExpectEqual(t, o.Timeout, time.Minute)
+	testing2.ExpectEqual(t, o.Name, "order")
+	testing2.ExpectEqual(t, o.Currency, "CNY")
+}
+
+func TestBindErrors(t *testing.T) {
+	body := `{"name":"o","country":"XX","currency":"ABC","status":"unknown","ref":"ref"}`
+	req := httptest.NewRequest("POST", "/orders?page=x&size=1000&tag=A&tag=b", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	var o order
+	err := NewRequest(req).Bind(&o)
+	errs, ok := err.(FieldErrors)
+	testing2.AssertEqual(t, ok, true)
+	testing2.ExpectEqual(t, errs, FieldErrors{{Field: "page", Rule: "type", Message: "could not be parsed as int"}})
+
+	req = httptest.NewRequest("POST", "/orders?page=-1&size=1000&tag=A&tag=b&tag=c", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	err = NewRequest(req).Bind(&o)
+	errs, ok = err.(FieldErrors)
+	testing2.AssertEqual(t, ok, true)
+	rules := make(map[string]string)
+	for _, e := range errs {
+		rules[e.Field] = e.Rule
+	}
+	testing2.ExpectEqual(t, rules, map[string]string{
+		"page":     "min",
+		"size":     "max",
+		"X-Token":  "required",
+		"tag":      "max",
+		"name":     "min",
+		"country":  "country",
+		"currency": "currency",
+		"status":   "oneof",
+		"ref":      "uuid",
+	})
+}
+
+func TestBindForm(t *testing.T) {
+	var form struct {
+		Name  string                  `form:"name" validate:"required"`
+		Age   *int                    `form:"age"`
+		Files []*multipart.FileHeader `form:"file"`
+	}
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader("name=bob&age=18"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	err := NewRequest(req).Bind(&form)
+	testing2.AssertEqual(t, err, nil)
+	testing2.ExpectEqual(t, form.Name, "bob")
+	testing2.ExpectEqual(t, *form.Age, 18)
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	w.WriteField("name", "alice")
+	fw, _ := w.CreateFormFile("file", "a.txt")
+	fw.Write([]byte("hello"))
+	w.Close()
+	req = httptest.NewRequest("POST", "/", &buf)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	err = NewRequest(req).Bind(&form)
+	testing2.AssertEqual(t, err, nil)
+	testing2.ExpectEqual(t, form.Name, "alice")
+	testing2.AssertEqual(t, len(form.Files), 1)
+	testing2.ExpectEqual(t, form.Files[0].Filename, "a.txt")
+
+	testing2.ExpectNotEqual(t, NewRequest(req).Bind(form), nil)
+}
+
+func TestValidateZero(t *testing.T) {
+	zero := 0
+	adult := 18
+	tests := []struct {
+		Object interface{}
+		Rules  []string
+	}{
+		{struct {
+			Age int `validate:"min=18"`
+		}{}, []string{"min"}},
+		{struct {
+			Age *int `validate:"min=18"`
+		}{Age: &zero}, []string{"min"}},
+		{struct {
+			Age *int `validate:"min=18"`
+		}{Age: &adult}, nil},
+		{struct {
+			Age *int `validate:"min=18"`
+		}{}, nil}, // missing
+		{struct {
+			Name string `validate:"min=1"`
+		}{}, []string{"min"}},
+		{struct {
+			Status string `validate:"oneof=open closed"`
+		}{}, []string{"oneof"}},
+		{struct {
+			Ref string `validate:"uuid"`
+		}{}, nil}, // empty format
+	}
+	for i, test := range tests {
+		var rules []string
+		if errs, ok := Validate(test.Object).(FieldErrors); ok {
+			for _, e := range errs {
+				rules = append(rules, e.Rule)
+			}
+		}
+		testing2.ExpectEqualL(t, rules, test.Rules, i)
+	}
+}
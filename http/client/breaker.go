@@ -0,0 +1,128 @@
+package client
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/wayn3h0/gop/clock"
+	"github.com/wayn3h0/gop/errors"
+)
+
+// ErrCircuitOpen is returned by circuit breaker if the requests to the host are rejected.
+var ErrCircuitOpen = errors.New("http: circuit breaker is open")
+
+// The states of circuit.
+const (
+	circuitClosed   = iota // requests are allowed
+	circuitOpen            // requests are rejected until the cooldown elapsed
+	circuitHalfOpen        // a probe request is allowed
+)
+
+// circuit represents the circuit of a host.
+type circuit struct {
+	state    int
+	failures int
+	openedAt time.Time
+}
+
+// CircuitBreaker represents a middleware rejects the requests to the host after consecutive failures (network errors or 5xx responses).
+// The circuit opens after the threshold of failures, a probe request is allowed after the cooldown,
+// the circuit is closed if the probe succeeds, otherwise it opens again.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	clock     clock.Clock
+	mutex     sync.Mutex
+	circuits  map[string]*circuit
+}
+
+// NewCircuitBreaker returns a new circuit breaker opens the circuit of host after threshold consecutive failures for cooldown.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = 1
+	}
+
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		clock:     clock.Default,
+		circuits:  make(map[string]*circuit),
+	}
+}
+
+// allow reports whether the request to the host is allowed.
+func (b *CircuitBreaker) allow(host string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	c, ok := b.circuits[host]
+	if !ok {
+		return true
+	}
+	switch c.state {
+	case circuitOpen:
+		if b.clock.Now().Sub(c.openedAt) < b.cooldown {
+			return false
+		}
+		c.state = circuitHalfOpen
+		return true
+	case circuitHalfOpen:
+		return false // the probe is in flight
+	}
+
+	return true
+}
+
+// record records the result of request to the host.
+func (b *CircuitBreaker) record(host string, failed bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	c, ok := b.circuits[host]
+	if !failed {
+		if ok {
+			delete(b.circuits, host)
+		}
+		return
+	}
+	if !ok {
+		c = &circuit{}
+		b.circuits[host] = c
+	}
+	c.failures++
+	if c.state == circuitHalfOpen || c.failures >= b.threshold {
+		c.state = circuitOpen
+		c.openedAt = b.clock.Now()
+	}
+}
+
+// cancel records the request to the host is canceled by the caller, it is neither a failure nor a success of host.
+// The circuit stays open if the canceled request is the probe, so the next request probes again.
+func (b *CircuitBreaker) cancel(host string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if c, ok := b.circuits[host]; ok && c.state == circuitHalfOpen {
+		c.state = circuitOpen
+	}
+}
+
+// Wrap implements Middleware interface.
+func (b *CircuitBreaker) Wrap(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		host := req.URL.Host
+		if !b.allow(host) {
+			return nil, ErrCircuitOpen
+		}
+
+		resp, err := next.RoundTrip(req)
+		if err != nil && req.Context().Err() != nil {
+			b.cancel(host)
+			return resp, err
+		}
+		b.record(host, err != nil || resp.StatusCode >= 500)
+
+		return resp, err
+	})
+}
@@ -0,0 +1,246 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/wayn3h0/gop/errors"
+	http2 "github.com/wayn3h0/gop/http"
+)
+
+// DefaultTimeout is the default timeout of request.
+const DefaultTimeout = 30 * time.Second
+
+// Client represents a HTTP client.
+type Client struct {
+	client      *http.Client
+	transport   http.RoundTripper
+	middlewares []Middleware
+	baseURL     *url.URL
+	timeout     time.Duration
+}
+
+// Option represents an option of client.
+type Option func(*Client)
+
+// WithTimeout sets the default timeout of request including retries and reading the body, zero means no timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithBaseURL sets the base URL for resolving the relative URL of request.
+func WithBaseURL(base *url.URL) Option {
+	return func(c *Client) {
+		c.baseURL = base
+	}
+}
+
+// WithTransport sets the underlying round tripper, http.DefaultTransport used if not set.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) {
+		c.transport = transport
+	}
+}
+
+// WithMiddlewares uses the middlewares for wrapping the round tripper, the first one is the outermost.
+func WithMiddlewares(middlewares ...Middleware) Option {
+	return func(c *Client) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+// NewClient returns a new client.
+func NewClient(options ...Option) *Client {
+	c := &Client{
+		transport: http.DefaultTransport,
+		timeout:   DefaultTimeout,
+	}
+	for _, option := range options {
+		option(c)
+	}
+
+	transport := c.transport
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		transport = c.middlewares[i].Wrap(transport)
+	}
+	c.client = &http.Client{
+		Transport: transport,
+	}
+
+	return c
+}
+
+// New is short to NewClient func.
+func New(options ...Option) *Client {
+	return NewClient(options...)
+}
+
+type timeoutKey struct{}
+
+// WithRequestTimeout returns the request with the timeout overrides the default one of client.
+func WithRequestTimeout(req *http.Request, timeout time.Duration) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), timeoutKey{}, timeout))
+}
+
+// cancelBody represents a response body cancels the context of request when closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements io.Closer interface.
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
+
+// Do sends the request and returns the response, the caller should close the body of response.
+// The relative URL is resolved by the base URL, the timeout is applied if the context of request has no deadline.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if c.baseURL != nil && !req.URL.IsAbs() {
+		req = req.Clone(req.Context())
+		req.URL = c.baseURL.ResolveReference(req.URL)
+		req.Host = ""
+	}
+
+	timeout := c.timeout
+	if t, ok := req.Context().Value(timeoutKey{}).(time.Duration); ok {
+		timeout = t
+	}
+	cancel := context.CancelFunc(func() {})
+	if _, ok := req.Context().Deadline(); !ok && timeout > 0 {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), timeout)
+		req = req.WithContext(ctx)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		cancel()
+		if e, ok := err.(*url.Error); ok {
+			err = e.Err // the method and URL are in the message
+		}
+		return nil, errors.Wrapf(err, "http: could not send request %s %s", req.Method, req.URL)
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+// StatusError represents the error of response with non-2xx status code.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+	Problem    *http2.Problem // problem details if the body is application/problem+json
+}
+
+// Error implements builtin.error interface.
+func (e *StatusError) Error() string {
+	if e.Problem != nil && len(e.Problem.Detail) > 0 {
+		return fmt.Sprintf("http: unexpected status %s: %s", e.Status, e.Problem.Detail)
+	}
+
+	return "http: unexpected status " + e.Status
+}
+
+// newStatusError returns the error of response with non-2xx status code.
+func newStatusError(resp *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	err := &StatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header,
+		Body:       body,
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "application/problem+json" {
+		var problem http2.Problem
+		if json.Unmarshal(body, &problem) == nil {
+			err.Problem = &problem
+		}
+	}
+
+	return err
+}
+
+// do sends the request with body encoded by marshal, and decodes the 2xx response into out by unmarshal.
+// It returns *StatusError for non-2xx response.
+func (c *Client) do(ctx context.Context, method, rawurl, mediaType string, in, out interface{}, marshal func(interface{}) ([]byte, error), unmarshal func([]byte, interface{}) error) error {
+	var body io.Reader
+	var data []byte
+	if in != nil {
+		var err error
+		data, err = marshal(in)
+		if err != nil {
+			return errors.Wrapf(err, "http: could not marshal entity of %s %s request", method, rawurl)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, rawurl, body)
+	if err != nil {
+		return errors.Wrapf(err, "http: could not create request %s %s", method, rawurl)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", mediaType)
+	}
+	req.Header.Set("Accept", mediaType)
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newStatusError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		io.Copy(ioutil.Discard, resp.Body)
+		return nil
+	}
+	data, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "http: could not read response body")
+	}
+	err = unmarshal(data, out)
+	if err != nil {
+		return errors.Wrapf(err, "http: could not unmarshal entity from %s %s response", method, rawurl)
+	}
+
+	return nil
+}
+
+// DoJSON sends the in as JSON and decodes the JSON response into out, the in and out can be nil.
+// It returns *StatusError if the status code of response is not 2xx.
+func (c *Client) DoJSON(ctx context.Context, method, rawurl string, in, out interface{}) error {
+	return c.do(ctx, method, rawurl, "application/json", in, out, json.Marshal, json.Unmarshal)
+}
+
+// DoXML sends the in as XML and decodes the XML response into out, the in and out can be nil.
+// It returns *StatusError if the status code of response is not 2xx.
+func (c *Client) DoXML(ctx context.Context, method, rawurl string, in, out interface{}) error {
+	marshal := func(v interface{}) ([]byte, error) {
+		data, err := xml.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(xml.Header), data...), nil
+	}
+
+	return c.do(ctx, method, rawurl, "application/xml", in, out, marshal, xml.Unmarshal)
+}
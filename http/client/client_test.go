@@ -0,0 +1,219 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wayn3h0/gop/errors"
+	http2 "github.com/wayn3h0/gop/http"
+	"github.com/wayn3h0/gop/http/middleware"
+	testing2 "github.com/wayn3h0/gop/testing"
+)
+
+type user struct {
+	XMLName xml.Name `json:"-" xml:"user"`
+	ID      int      `json:"id" xml:"id"`
+	Name    string   `json:"name" xml:"name"`
+}
+
+func TestClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/missing" {
+			http2.DefaultErrorHandler.Handle(rw, req, http2.NewError(http.StatusNotFound, "not_found", "user not found"))
+			return
+		}
+		var u user
+		rw.Header().Set("Content-Type", req.Header.Get("Content-Type"))
+		if req.Header.Get("Content-Type") == "application/xml" {
+			xml.NewDecoder(req.Body).Decode(&u)
+			u.ID = 1
+			xml.NewEncoder(rw).Encode(&u)
+			return
+		}
+		json.NewDecoder(req.Body).Decode(&u)
+		u.ID = 1
+		json.NewEncoder(rw).Encode(&u)
+	}))
+	defer server.Close()
+
+	base, _ := url.Parse(server.URL + "/api/")
+	c := New(WithBaseURL(base))
+
+	var out user
+	err := c.DoJSON(context.Background(), "POST", "users", &user{Name: "json"}, &out)
+	testing2.AssertEqual(t, err, nil)
+	testing2.ExpectEqual(t, out.ID, 1)
+	testing2.ExpectEqual(t, out.Name, "json")
+
+	out = user{}
+	err = c.DoXML(context.Background(), "POST", "users", &user{Name: "xml"}, &out)
+	testing2.AssertEqual(t, err, nil)
+	testing2.ExpectEqual(t, out.ID, 1)
+	testing2.ExpectEqual(t, out.Name, "xml")
+
+	err = c.DoJSON(context.Background(), "GET", "/missing", nil, &out)
+	statusErr, ok := err.(*StatusError)
+	testing2.AssertEqual(t, ok, true)
+	testing2.ExpectEqual(t, statusErr.StatusCode, http.StatusNotFound)
+	testing2.AssertNotEqual(t, statusErr.Problem, nil)
+	testing2.ExpectEqual(t, statusErr.Problem.Code, "not_found")
+	testing2.ExpectEqual(t, statusErr.Problem.Detail, "user not found")
+}
+
+func TestClientTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	c := New(WithTimeout(time.Minute))
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	start := time.Now()
+	_, err := c.Do(WithRequestTimeout(req, 20*time.Millisecond))
+	testing2.AssertNotEqual(t, err, nil)
+	testing2.ExpectEqual(t, time.Since(start) < 500*time.Millisecond, true)
+
+	c = New(WithTimeout(20 * time.Millisecond))
+	err = c.DoJSON(context.Background(), "GET", server.URL, nil, nil)
+	testing2.ExpectNotEqual(t, err, nil)
+}
+
+func TestRetry(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		if atomic.AddInt32(&calls, 1)%3 != 0 {
+			rw.Header().Set("Retry-After", "1")
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		rw.Write(body)
+	}))
+	defer server.Close()
+
+	retry := NewRetry(3, func(int) time.Duration { return time.Millisecond })
+	clock := testing2.NewFakeClock(time.Now())
+	retry.clock = clock
+	c := New(WithMiddlewares(retry))
+	go func() {
+		for i := 0; i < 2; i++ {
+			clock.BlockUntil(1)
+			clock.Advance(time.Second) // Retry-After
+		}
+	}()
+	resp, err := c.Do(mustRequest("PUT", server.URL, "data"))
+	testing2.AssertEqual(t, err, nil)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	testing2.ExpectEqual(t, resp.StatusCode, http.StatusOK)
+	testing2.ExpectEqual(t, string(body), "data") // the body is replayed
+	testing2.ExpectEqual(t, atomic.LoadInt32(&calls), int32(3))
+
+	// not idempotent
+	atomic.StoreInt32(&calls, 0)
+	resp, err = c.Do(mustRequest("POST", server.URL, "data"))
+	testing2.AssertEqual(t, err, nil)
+	resp.Body.Close()
+	testing2.ExpectEqual(t, resp.StatusCode, http.StatusServiceUnavailable)
+	testing2.ExpectEqual(t, atomic.LoadInt32(&calls), int32(1))
+
+	// canceled while waiting
+	atomic.StoreInt32(&calls, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	req := mustRequest("POST", server.URL, "data")
+	req.Header.Set(IdempotencyKeyHeader, "key")
+	go func() {
+		clock.BlockUntil(1)
+		cancel()
+	}()
+	_, err = c.Do(req.WithContext(ctx))
+	testing2.ExpectEqual(t, errors.Equal(err, context.Canceled), true)
+	testing2.ExpectEqual(t, atomic.LoadInt32(&calls), int32(1))
+}
+
+func mustRequest(method, url, body string) *http.Request {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		panic(err)
+	}
+
+	return req
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(100*time.Millisecond, time.Second)
+	for i, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		d := backoff(i)
+		testing2.ExpectEqualL(t, d >= want/2 && d <= want, true, i)
+	}
+	testing2.ExpectEqual(t, backoff(100) <= time.Second, true)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var calls, status int32 = 0, http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		rw.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	defer server.Close()
+
+	breaker := NewCircuitBreaker(2, time.Minute)
+	clock := testing2.NewFakeClock(time.Now())
+	breaker.clock = clock
+	c := New(WithMiddlewares(breaker))
+	get := func() error {
+		return c.DoJSON(context.Background(), "GET", server.URL, nil, nil)
+	}
+
+	testing2.ExpectEqual(t, get().(*StatusError).StatusCode, http.StatusInternalServerError)
+	testing2.ExpectEqual(t, get().(*StatusError).StatusCode, http.StatusInternalServerError)
+	testing2.ExpectEqual(t, errors.Equal(get(), ErrCircuitOpen), true)
+	testing2.ExpectEqual(t, atomic.LoadInt32(&calls), int32(2))
+
+	// the probe fails
+	clock.Advance(time.Minute)
+	testing2.ExpectEqual(t, get().(*StatusError).StatusCode, http.StatusInternalServerError)
+	testing2.ExpectEqual(t, errors.Equal(get(), ErrCircuitOpen), true)
+	testing2.ExpectEqual(t, atomic.LoadInt32(&calls), int32(3))
+
+	// the probe succeeds
+	atomic.StoreInt32(&status, http.StatusOK)
+	clock.Advance(time.Minute)
+	testing2.ExpectEqual(t, get(), nil)
+	testing2.ExpectEqual(t, get(), nil)
+	testing2.ExpectEqual(t, atomic.LoadInt32(&calls), int32(5))
+}
+
+func TestRequestID(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte(req.Header.Get(middleware.DefaultRequestIDHeader)))
+	}))
+	defer backend.Close()
+
+	c := New(WithMiddlewares(NewRequestID("")))
+	handler := middleware.NewRequestID("").Wrap(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		resp, err := c.Do(mustRequest("GET", backend.URL, "").WithContext(req.Context()))
+		testing2.AssertEqual(t, err, nil)
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		rw.Write(body)
+	}))
+
+	rw := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(middleware.DefaultRequestIDHeader, "request-1")
+	handler.ServeHTTP(rw, req)
+	testing2.ExpectEqual(t, rw.Body.String(), "request-1")
+}
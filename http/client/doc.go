@@ -0,0 +1,18 @@
+/*
+
+Package client providers a HTTP client mirrors the server side of http package.
+
+The middlewares wrap the round tripper, the first one is the outermost:
+
+	c := client.New(
+		client.WithTimeout(5*time.Second),
+		client.WithMiddlewares(
+			client.NewRequestID(""),
+			client.NewCircuitBreaker(5, 30*time.Second),
+			client.NewRetry(3, client.ExponentialBackoff(100*time.Millisecond, 2*time.Second)),
+		),
+	)
+	err := c.DoJSON(req.Context(), "GET", "https://example.com/users/1", nil, &user)
+
+*/
+package client
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/wayn3h0/gop/http/middleware"
+)
+
+// Middleware represents a wrapper for http round tripper.
+type Middleware interface {
+	// Wrap wraps the round tripper.
+	Wrap(http.RoundTripper) http.RoundTripper
+}
+
+// MiddlewareFunc an adapter to allow the use of ordinary functions as middlewares.
+type MiddlewareFunc func(http.RoundTripper) http.RoundTripper
+
+// Wrap wraps the m to http.RoundTripper.
+// Wrap implements Middleware interface.
+func (m MiddlewareFunc) Wrap(rt http.RoundTripper) http.RoundTripper {
+	return m(rt)
+}
+
+// RoundTripperFunc an adapter to allow the use of ordinary functions as round trippers.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper interface.
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// NewRequestID returns a middleware propagates the request ID of the incoming request (set by middleware.NewRequestID) in the context of request.
+// The ID is set in the header, middleware.DefaultRequestIDHeader used if header is empty.
+func NewRequestID(header string) Middleware {
+	if len(header) == 0 {
+		header = middleware.DefaultRequestIDHeader
+	}
+
+	return MiddlewareFunc(func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			id := middleware.RequestID(req)
+			if len(id) > 0 && len(req.Header.Get(header)) == 0 {
+				req = req.Clone(req.Context()) // the round tripper must not modify the request
+				req.Header.Set(header, id)
+			}
+
+			return next.RoundTrip(req)
+		})
+	})
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/wayn3h0/gop/clock"
+)
+
+// Backoff represents a func returns the delay before the retry attempt (starts from 0).
+type Backoff func(attempt int) time.Duration
+
+// ExponentialBackoff returns a backoff doubles the delay from base for each attempt up to max, with random jitter of half the delay.
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := max
+		if attempt < 32 && base<<uint(attempt) > 0 && base<<uint(attempt) < max {
+			d = base << uint(attempt)
+		}
+		if d <= 1 {
+			return d
+		}
+
+		return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+	}
+}
+
+// IdempotencyKeyHeader is the header marks a non-idempotent request safe to retry.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotent reports whether the request can be sent more than once.
+func idempotent(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false // the body cannot be replayed
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return len(req.Header.Get(IdempotencyKeyHeader)) > 0
+}
+
+// retryable reports whether the status code of response is transient.
+func retryable(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// retryAfter returns the delay of Retry-After header (seconds or HTTP date).
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if len(value) == 0 {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// Retry represents a middleware retries the idempotent requests (or with Idempotency-Key header) on network errors
+// and 429 (Too Many Requests), 502 (Bad Gateway), 503 (Service Unavailable) or 504 (Gateway Timeout) responses.
+type Retry struct {
+	max     int
+	backoff Backoff
+	clock   clock.Clock
+}
+
+// NewRetry returns a new retry middleware retries the request at most max times, it waits the delay of backoff between the attempts,
+// or the delay of Retry-After header if present.
+func NewRetry(max int, backoff Backoff) *Retry {
+	return &Retry{
+		max:     max,
+		backoff: backoff,
+		clock:   clock.Default,
+	}
+}
+
+// Wrap implements Middleware interface.
+func (r *Retry) Wrap(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if r.max <= 0 || !idempotent(req) {
+			return next.RoundTrip(req)
+		}
+
+		ctx := req.Context()
+		for attempt := 0; ; attempt++ {
+			attemptReq := req
+			if attempt > 0 {
+				attemptReq = req.Clone(ctx)
+				if req.GetBody != nil {
+					body, err := req.GetBody()
+					if err != nil {
+						return nil, err
+					}
+					attemptReq.Body = body
+				}
+			}
+
+			resp, err := next.RoundTrip(attemptReq)
+			if err == nil && !retryable(resp.StatusCode) {
+				return resp, nil
+			}
+			if attempt >= r.max || ctx.Err() != nil {
+				return resp, err
+			}
+
+			delay := r.backoff(attempt)
+			if resp != nil {
+				if d, ok := retryAfter(resp, r.clock.Now()); ok {
+					delay = d
+				}
+				io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1<<16)) // for reusing the connection
+				resp.Body.Close()
+			}
+
+			timer := r.clock.NewTimer(delay)
+			select {
+			case <-timer.C():
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			}
+		}
+	})
+}
@@ -31,11 +31,11 @@ func (s *ServeMux) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 
 func (s *ServeMux) wrap(handler http.Handler) httprouter.Handle {
 	return func(rw http.ResponseWriter, r *http.Request, params httprouter.Params) {
-		r2 := http2.NewRequest(r)
+		list := make([]http2.Param, 0, len(params))
 		for _, v := range params {
-			r2.SetParameter(v.Key, v.Value)
+			list = append(list, http2.Param{Key: v.Key, Value: v.Value})
 		}
-		handler.ServeHTTP(rw, r2.Request)
+		handler.ServeHTTP(rw, http2.WithParams(r, list...))
 	}
 }
 
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	http2 "github.com/wayn3h0/gop/http"
+	"github.com/wayn3h0/gop/log"
+)
+
+// NewAccessLog returns a middleware logs each request as info in format:
+// ip method "uri" protocol status size duration "referer" "user-agent" "request-id"
+// The values supplied by client are quoted, so they cannot forge the log lines.
+func NewAccessLog(logger *log.Logger) http2.Middleware {
+	if logger == nil {
+		logger = log.DefaultLogger
+	}
+
+	return http2.MiddlewareFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			w := wrap(rw)
+			defer func() {
+				status := w.Status
+				if status == 0 {
+					status = http.StatusOK
+				}
+				id := RequestID(req)
+				if len(id) == 0 {
+					id = "-"
+				}
+				logger.Infof("%s %s %q %s %d %d %s %q %q %q",
+					RealIP(req), req.Method, req.RequestURI, req.Proto, status, w.Size, time.Since(start), req.Referer(), req.UserAgent(), id)
+			}()
+
+			next.ServeHTTP(w, req)
+		})
+	})
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+
+	http2 "github.com/wayn3h0/gop/http"
+)
+
+// Principal represents an authenticated principal.
+type Principal struct {
+	Name   string                 // user name of Basic, subject of JWT or key id of HMAC
+	Scheme string                 // "Basic", "Bearer" or "HMAC"
+	Claims map[string]interface{} // claims of JWT
+}
+
+type principalKey struct{}
+
+// withPrincipal returns the request with the principal in context.
+func withPrincipal(req *http.Request, principal *Principal) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), principalKey{}, principal))
+}
+
+// PrincipalOf returns the principal authenticated by the authentication middlewares, it returns nil if not authenticated.
+func PrincipalOf(req *http.Request) *Principal {
+	principal, _ := req.Context().Value(principalKey{}).(*Principal)
+	return principal
+}
+
+// BasicAuthFunc represents a func verifies the credentials of Basic authentication, it returns nil if the credentials are invalid.
+type BasicAuthFunc func(username, password string) (*Principal, error)
+
+// BasicCredentials returns a BasicAuthFunc verifies the credentials by the map of username to password.
+// The passwords are compared in constant time.
+func BasicCredentials(credentials map[string]string) BasicAuthFunc {
+	return func(username, password string) (*Principal, error) {
+		expected, ok := credentials[username]
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(password)) != 1 || !ok {
+			return nil, nil
+		}
+
+		return &Principal{Name: username}, nil
+	}
+}
+
+// NewBasicAuth returns a middleware authenticates the request by HTTP Basic authentication, the credentials are verified by the func.
+// It responds with 401 (Unauthorized) and WWW-Authenticate header if the credentials are missing or invalid,
+// or 500 (Internal Server Error) if the func fails.
+func NewBasicAuth(realm string, verify BasicAuthFunc) http2.Middleware {
+	challenge := "Basic realm=" + strconv.Quote(realm) + `, charset="UTF-8"`
+
+	return http2.MiddlewareFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			username, password, ok := req.BasicAuth()
+			if !ok {
+				unauthorized(rw, challenge)
+				return
+			}
+			principal, err := verify(username, password)
+			if err != nil {
+				http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			if principal == nil {
+				unauthorized(rw, challenge)
+				return
+			}
+			if len(principal.Scheme) == 0 {
+				principal.Scheme = "Basic"
+			}
+
+			next.ServeHTTP(rw, withPrincipal(req, principal))
+		})
+	})
+}
+
+// unauthorized responds with 401 (Unauthorized) and the challenge.
+func unauthorized(rw http.ResponseWriter, challenge string) {
+	rw.Header().Set("WWW-Authenticate", challenge)
+	http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
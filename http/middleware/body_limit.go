@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	http2 "github.com/wayn3h0/gop/http"
+)
+
+// DefaultBodyLimit is the default limit of request body decompressed by compress middleware or buffered by HMAC auth middleware if the body limit is not set.
+const DefaultBodyLimit = 10 << 20
+
+type bodyLimitKey struct{}
+
+// bodyLimit returns the limit of request body set by body limit middleware, or DefaultBodyLimit if not set.
+func bodyLimit(req *http.Request) int64 {
+	if limit, ok := req.Context().Value(bodyLimitKey{}).(int64); ok {
+		return limit
+	}
+
+	return DefaultBodyLimit
+}
+
+// NewBodyLimit returns a middleware limits the size of request body.
+// It responds with 413 (Request Entity Too Large) if the Content-Length exceeds the limit,
+// otherwise the reading of body fails after the limit reached.
+// The limit applies to the decompressed body as well if the compress middleware is used inside.
+func NewBodyLimit(limit int64) http2.Middleware {
+	return http2.MiddlewareFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			if req.ContentLength > limit {
+				http.Error(rw, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			req.Body = http.MaxBytesReader(rw, req.Body, limit)
+			req = req.WithContext(context.WithValue(req.Context(), bodyLimitKey{}, limit))
+			next.ServeHTTP(rw, req)
+		})
+	})
+}
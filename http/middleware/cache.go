@@ -0,0 +1,576 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/gob"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wayn3h0/gop/cache"
+	"github.com/wayn3h0/gop/errors"
+	http2 "github.com/wayn3h0/gop/http"
+	"github.com/wayn3h0/gop/log"
+)
+
+// CacheTagHeader is the response header lists the tags of response for invalidation (comma separated), it's removed before responding.
+const CacheTagHeader = "Cache-Tag"
+
+// DefaultCacheMaxSize is the maximum size of response body to cache.
+const DefaultCacheMaxSize = 1 << 20
+
+// cachedResponse represents a cached response.
+type cachedResponse struct {
+	Status  int
+	Header  http.Header
+	Body    []byte
+	Tags    []string
+	Created time.Time
+}
+
+// cachedVary represents the headers vary the responses of a resource.
+type cachedVary struct {
+	Headers []string
+}
+
+// cachedTag represents the invalidation of a tag.
+type cachedTag struct {
+	Invalidated time.Time
+}
+
+// registers types for gob encoding (e.g. memcached container).
+func init() {
+	gob.Register(&cachedResponse{})
+	gob.Register(&cachedVary{})
+	gob.Register(&cachedTag{})
+}
+
+// ResponseCache represents a middleware caches the responses of GET and HEAD requests in cache.Cache.
+//
+// The responses are keyed by scheme, host, path, query and the request headers listed in Vary, HEAD requests are served by the responses of GET.
+// Only 200 (OK) responses are cached, unless the response has Set-Cookie, Cache-Control with no-store, no-cache or private, or Vary with "*".
+// The max-age (or s-maxage) of Cache-Control is used as time to live, the default one is used otherwise.
+// The requests with Authorization, Cookie (configurable by BypassCookies) or Cache-Control no-store bypass the cache, since the responses may be personalized,
+// the ones with Cache-Control no-cache or max-age=0 refresh it.
+//
+// The ETag (SHA-1 of body) and Last-Modified are generated if absent, the conditional requests are responded with 304 (Not Modified).
+// The cached responses can be invalidated by path or route pattern (Invalidate), or by tag (InvalidateTag) which is listed in Cache-Tag header by handler.
+type ResponseCache struct {
+	cache     *cache.Cache
+	ttl       time.Duration
+	logger    *log.Logger
+	anyCookie bool     // any cookie bypasses the cache
+	cookies   []string // the cookies bypass the cache
+}
+
+// ResponseCacheOption represents an option of response cache.
+type ResponseCacheOption func(*ResponseCache)
+
+// BypassCookies sets the cookies bypass the cache (e.g. the session cookie), the requests with other cookies (e.g. analytics cookies) are served from the cache.
+// The requests with any cookie bypass the cache by default, none does if no name is given.
+// The handler should respond with Vary: Cookie or Cache-Control: private if the response depends on the other cookies.
+func BypassCookies(names ...string) ResponseCacheOption {
+	return func(c *ResponseCache) {
+		c.anyCookie = false
+		c.cookies = append(c.cookies, names...)
+	}
+}
+
+// NewResponseCache returns a new response cache with the default time to live.
+func NewResponseCache(c *cache.Cache, ttl time.Duration, logger *log.Logger, options ...ResponseCacheOption) (*ResponseCache, error) {
+	if c == nil {
+		return nil, errors.New("http: cache of response cache cannot be nil")
+	}
+	if logger == nil {
+		logger = log.DefaultLogger
+	}
+
+	rc := &ResponseCache{
+		cache:     c,
+		ttl:       ttl,
+		logger:    logger,
+		anyCookie: true,
+	}
+	for _, option := range options {
+		option(rc)
+	}
+
+	return rc, nil
+}
+
+// bypass reports whether the request bypasses the cache.
+func (c *ResponseCache) bypass(req *http.Request) bool {
+	if (req.Method != "GET" && req.Method != "HEAD") || len(req.Header.Get("Authorization")) > 0 {
+		return true
+	}
+	if c.anyCookie {
+		return len(req.Header.Get("Cookie")) > 0
+	}
+	for _, name := range c.cookies {
+		if _, err := req.Cookie(name); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// baseKey returns the key of resource.
+func baseKey(req *http.Request) string {
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+
+	return "http:GET " + scheme + "://" + strings.ToLower(req.Host) + req.URL.Path + "?" + req.URL.Query().Encode()
+}
+
+// variantKey returns the key of the response varied by the request headers, it differs from the base key even if no header varies.
+func variantKey(base string, req *http.Request, headers []string) string {
+	key := base + "|"
+	for _, name := range headers {
+		key += "|" + name + "=" + strings.Join(req.Header[name], ",")
+	}
+
+	return key
+}
+
+// tagKey returns the key of tag.
+func tagKey(tag string) string {
+	return "http:tag " + tag
+}
+
+// pathTag returns the tag of path, every response is tagged with its path and the route pattern it matched.
+func pathTag(path string) string {
+	return "path:" + path
+}
+
+// directives parses the Cache-Control header.
+func directives(header string) map[string]string {
+	m := make(map[string]string)
+	for _, item := range strings.Split(header, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		name, value := item, ""
+		if i := strings.IndexByte(item, '='); i >= 0 {
+			name, value = item[:i], strings.Trim(item[i+1:], `"`)
+		}
+		m[strings.ToLower(name)] = value
+	}
+
+	return m
+}
+
+// Invalidate invalidates the cached responses of the path (e.g. "/users/42"),
+// or the ones of the route pattern matched by the requests (e.g. "/users/:id"), which is known if the requests are dispatched by TreeMux.
+func (c *ResponseCache) Invalidate(path string) error {
+	return c.InvalidateTag(pathTag(path))
+}
+
+// InvalidateTag invalidates the cached responses tagged with the tag.
+// The record of tag expires with the last response tagged with it, nothing is saved if no response is tagged.
+func (c *ResponseCache) InvalidateTag(tag string) error {
+	key := tagKey(tag)
+	item, err := c.cache.Get(key)
+	if err != nil {
+		return errors.Wrapf(err, "http: could not get cache tag %q", tag)
+	}
+	if item == nil { // the responses are invalid without the record
+		return nil
+	}
+	expiration := item.AbsoluteExpirationTime
+	item, err = cache.NewItem(key, &cachedTag{Invalidated: time.Now()})
+	if err != nil {
+		return err
+	}
+	item.SetAbsoluteExpiration(expiration)
+	err = c.cache.Save(item)
+	if err != nil {
+		return errors.Wrapf(err, "http: could not invalidate cache tag %q", tag)
+	}
+
+	return nil
+}
+
+// valid reports whether none of the tags of response is invalidated after it's cached.
+// The tag is considered invalidated if its record is missing (e.g. evicted).
+func (c *ResponseCache) valid(resp *cachedResponse) (bool, error) {
+	for _, tag := range resp.Tags {
+		item, err := c.cache.Get(tagKey(tag))
+		if err != nil {
+			return false, err
+		}
+		if item == nil {
+			return false, nil
+		}
+		if t, ok := item.Value.(*cachedTag); !ok || !t.Invalidated.Before(resp.Created) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// lookup returns the cached response for the request, it returns nil if not found.
+func (c *ResponseCache) lookup(req *http.Request) (*cachedResponse, error) {
+	base := baseKey(req)
+	item, err := c.cache.Get(base)
+	if err != nil || item == nil {
+		return nil, err
+	}
+	vary, ok := item.Value.(*cachedVary)
+	if !ok {
+		return nil, nil
+	}
+
+	item, err = c.cache.Get(variantKey(base, req, vary.Headers))
+	if err != nil || item == nil {
+		return nil, err
+	}
+	resp, ok := item.Value.(*cachedResponse)
+	if !ok {
+		return nil, nil
+	}
+	ok, err = c.valid(resp)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// store saves the response for the request if it's cacheable.
+func (c *ResponseCache) store(req *http.Request, resp *cachedResponse) error {
+	if resp.Status != http.StatusOK || len(resp.Header["Set-Cookie"]) > 0 {
+		return nil
+	}
+	cc := directives(resp.Header.Get("Cache-Control"))
+	if _, ok := cc["no-store"]; ok {
+		return nil
+	}
+	if _, ok := cc["no-cache"]; ok {
+		return nil
+	}
+	if _, ok := cc["private"]; ok {
+		return nil
+	}
+	ttl := c.ttl
+	for _, name := range []string{"max-age", "s-maxage"} { // s-maxage takes precedence for shared caches
+		if v, ok := cc[name]; ok {
+			seconds, err := strconv.Atoi(v)
+			if err == nil {
+				ttl = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	if ttl <= 0 {
+		return nil
+	}
+
+	var headers []string
+	for _, value := range resp.Header["Vary"] {
+		for _, name := range strings.Split(value, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "*" {
+				return nil
+			}
+			if len(name) > 0 {
+				headers = append(headers, name)
+			}
+		}
+	}
+	sort.Strings(headers)
+	expiration := resp.Created.Add(ttl)
+
+	// ensure the records of tags exist until the response expires
+	for _, tag := range resp.Tags {
+		key := tagKey(tag)
+		item, err := c.cache.Get(key)
+		if err != nil {
+			return errors.Wrapf(err, "http: could not get cache tag %q", tag)
+		}
+		value := &cachedTag{}
+		if item != nil {
+			if !item.AbsoluteExpirationTime.Before(expiration) {
+				continue
+			}
+			if t, ok := item.Value.(*cachedTag); ok {
+				value = t
+			}
+		}
+		item, err = cache.NewItem(key, value)
+		if err != nil {
+			return err
+		}
+		item.SetAbsoluteExpiration(expiration)
+		err = c.cache.Save(item)
+		if err != nil {
+			return errors.Wrapf(err, "http: could not save cache tag %q", tag)
+		}
+	}
+
+	base := baseKey(req)
+	item, err := cache.NewItem(base, &cachedVary{Headers: headers})
+	if err != nil {
+		return err
+	}
+	item.SetAbsoluteExpiration(expiration)
+	err = c.cache.Save(item)
+	if err != nil {
+		return errors.Wrapf(err, "http: could not save cached response of %s", req.URL.Path)
+	}
+	item, err = cache.NewItem(variantKey(base, req, headers), resp)
+	if err != nil {
+		return err
+	}
+	item.SetAbsoluteExpiration(expiration)
+	err = c.cache.Save(item)
+	if err != nil {
+		return errors.Wrapf(err, "http: could not save cached response of %s", req.URL.Path)
+	}
+
+	return nil
+}
+
+// notModified reports whether the response matches the conditional request.
+func notModified(req *http.Request, header http.Header) bool {
+	if match := req.Header.Get("If-None-Match"); len(match) > 0 {
+		etag := strings.TrimPrefix(header.Get("ETag"), "W/")
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if since := req.Header.Get("If-Modified-Since"); len(since) > 0 {
+		t, err := http.ParseTime(since)
+		if err != nil {
+			return false
+		}
+		modified, err := http.ParseTime(header.Get("Last-Modified"))
+		if err != nil {
+			return false
+		}
+		return !modified.After(t)
+	}
+
+	return false
+}
+
+// respond writes the cached response, or 304 (Not Modified) for the matched conditional request.
+func respond(rw http.ResponseWriter, req *http.Request, resp *cachedResponse, status string) {
+	header := rw.Header()
+	for name, values := range resp.Header {
+		if name == "Vary" {
+			header[name] = append(header[name], values...)
+		} else {
+			header[name] = append([]string(nil), values...)
+		}
+	}
+	header.Set("X-Cache", status)
+	if status == "HIT" {
+		header.Set("Age", strconv.Itoa(int(time.Since(resp.Created)/time.Second)))
+	}
+
+	if resp.Status == http.StatusOK && notModified(req, resp.Header) {
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+		rw.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	header.Set("Content-Length", strconv.Itoa(len(resp.Body)))
+	rw.WriteHeader(resp.Status)
+	if req.Method != "HEAD" {
+		rw.Write(resp.Body)
+	}
+}
+
+// cacheWriter represents a response writer buffers the response for caching.
+// It turns to pass through if the response is streaming (flushed) or too large.
+type cacheWriter struct {
+	http.ResponseWriter
+	header  http.Header
+	status  int
+	buffer  bytes.Buffer
+	through bool
+}
+
+// Header implements http.ResponseWriter interface.
+func (w *cacheWriter) Header() http.Header {
+	if w.through {
+		return w.ResponseWriter.Header()
+	}
+
+	return w.header
+}
+
+// WriteHeader implements http.ResponseWriter interface.
+func (w *cacheWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = status
+	if w.through {
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+// Write implements http.ResponseWriter interface.
+func (w *cacheWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.through && w.buffer.Len()+len(data) > DefaultCacheMaxSize {
+		w.passThrough()
+	}
+	if w.through {
+		return w.ResponseWriter.Write(data)
+	}
+
+	return w.buffer.Write(data)
+}
+
+// passThrough writes the buffered response and turns to pass through.
+func (w *cacheWriter) passThrough() {
+	if w.through {
+		return
+	}
+	w.through = true
+
+	header := w.ResponseWriter.Header()
+	for name, values := range w.header {
+		if name == "Vary" {
+			header[name] = append(header[name], values...)
+		} else {
+			header[name] = values
+		}
+	}
+	header.Del(CacheTagHeader)
+	header.Set("X-Cache", "MISS")
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if w.buffer.Len() > 0 {
+		w.ResponseWriter.Write(w.buffer.Bytes())
+		w.buffer.Reset()
+	}
+}
+
+// Flush implements http.Flusher interface.
+func (w *cacheWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.passThrough()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker interface.
+func (w *cacheWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("http: response writer does not support hijacking")
+	}
+	w.through = true // the connection is taken over
+
+	return hijacker.Hijack()
+}
+
+// Wrap implements http.Middleware interface.
+func (c *ResponseCache) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if c.bypass(req) {
+			next.ServeHTTP(rw, req)
+			return
+		}
+		cc := directives(req.Header.Get("Cache-Control"))
+		if _, ok := cc["no-store"]; ok {
+			next.ServeHTTP(rw, req)
+			return
+		}
+
+		_, noCache := cc["no-cache"]
+		if v, ok := cc["max-age"]; ok && v == "0" {
+			noCache = true
+		}
+		if !noCache {
+			resp, err := c.lookup(req)
+			if err != nil {
+				c.logger.Warnf("http: could not look up cached response of %s: %s", req.URL.Path, errors.TreeMessage(err))
+			}
+			if resp != nil {
+				respond(rw, req, resp, "HIT")
+				return
+			}
+		}
+		if req.Method == "HEAD" { // the body of response is unknown
+			next.ServeHTTP(rw, req)
+			return
+		}
+
+		w := &cacheWriter{
+			ResponseWriter: rw,
+			header:         make(http.Header),
+		}
+		req = http2.NewRequest(req).Request // the route pattern set by mux is visible through the parameters
+		next.ServeHTTP(w, req)
+		if w.through {
+			return
+		}
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+
+		now := time.Now()
+		resp := &cachedResponse{
+			Status:  w.status,
+			Header:  w.header,
+			Body:    w.buffer.Bytes(),
+			Tags:    []string{pathTag(req.URL.Path)},
+			Created: now,
+		}
+		if pattern := http2.Pattern(req); len(pattern) > 0 && pattern != req.URL.Path {
+			resp.Tags = append(resp.Tags, pathTag(pattern))
+		}
+		for _, value := range w.header[CacheTagHeader] {
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); len(tag) > 0 {
+					resp.Tags = append(resp.Tags, tag)
+				}
+			}
+		}
+		w.header.Del(CacheTagHeader)
+		if w.status == http.StatusOK {
+			if len(w.header.Get("ETag")) == 0 {
+				sum := sha1.Sum(resp.Body)
+				w.header.Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+			}
+			if len(w.header.Get("Last-Modified")) == 0 {
+				w.header.Set("Last-Modified", now.UTC().Format(http.TimeFormat))
+			}
+			if len(w.header.Get("Content-Type")) == 0 {
+				w.header.Set("Content-Type", http.DetectContentType(resp.Body))
+			}
+		}
+
+		err := c.store(req, resp)
+		if err != nil {
+			c.logger.Warnf("http: could not cache response of %s: %s", req.URL.Path, errors.TreeMessage(err))
+		}
+		respond(rw, req, resp, "MISS")
+	})
+}
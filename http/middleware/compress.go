@@ -0,0 +1,432 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/wayn3h0/gop/errors"
+	http2 "github.com/wayn3h0/gop/http"
+)
+
+// DefaultCompressMinSize is the default minimum size of response body to compress.
+const DefaultCompressMinSize = 1024
+
+// Compressor represents a func returns the writer compresses the data into w with the level.
+type Compressor func(w io.Writer, level int) (io.WriteCloser, error)
+
+// Decompressor represents a func returns the reader decompresses the data from r.
+type Decompressor func(r io.Reader) (io.ReadCloser, error)
+
+// coding represents a content coding.
+type coding struct {
+	Name         string
+	Compressor   Compressor
+	Decompressor Decompressor
+}
+
+var (
+	codingsMutex sync.RWMutex
+	codings      = []coding{ // in preference order
+		{
+			Name: "gzip",
+			Compressor: func(w io.Writer, level int) (io.WriteCloser, error) {
+				return gzip.NewWriterLevel(w, level)
+			},
+			Decompressor: func(r io.Reader) (io.ReadCloser, error) {
+				return gzip.NewReader(r)
+			},
+		},
+		{
+			Name: "deflate", // zlib format (RFC 1950)
+			Compressor: func(w io.Writer, level int) (io.WriteCloser, error) {
+				return zlib.NewWriterLevel(w, level)
+			},
+			Decompressor: zlib.NewReader,
+		},
+	}
+)
+
+// RegisterCoding registers the compressor and decompressor for the content coding (e.g. "br"), the decompressor is optional.
+// The registered coding takes precedence over the built-in gzip and deflate when they are accepted equally.
+func RegisterCoding(name string, compressor Compressor, decompressor Decompressor) {
+	codingsMutex.Lock()
+	defer codingsMutex.Unlock()
+
+	name = strings.ToLower(name)
+	list := []coding{{Name: name, Compressor: compressor, Decompressor: decompressor}}
+	for _, c := range codings {
+		if c.Name != name {
+			list = append(list, c)
+		}
+	}
+	codings = list
+}
+
+// lookupCoding returns the content coding by name.
+func lookupCoding(name string) (coding, bool) {
+	codingsMutex.RLock()
+	defer codingsMutex.RUnlock()
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, c := range codings {
+		if c.Name == name {
+			return c, true
+		}
+	}
+
+	return coding{}, false
+}
+
+// negotiateCoding returns the content coding accepted by the Accept-Encoding header with the highest quality.
+func negotiateCoding(header string) (coding, bool) {
+	qualities := make(map[string]float64)
+	for _, item := range strings.Split(header, ",") {
+		parts := strings.Split(item, ";")
+		name := strings.ToLower(strings.TrimSpace(parts[0]))
+		if len(name) == 0 {
+			continue
+		}
+		quality := 1.0
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				q, err := strconv.ParseFloat(param[2:], 64)
+				if err == nil {
+					quality = q
+				}
+			}
+		}
+		qualities[name] = quality
+	}
+
+	codingsMutex.RLock()
+	defer codingsMutex.RUnlock()
+
+	var best coding
+	bestQuality := 0.0
+	for _, c := range codings {
+		quality, ok := qualities[c.Name]
+		if !ok {
+			quality = qualities["*"]
+		}
+		if quality > bestQuality {
+			best, bestQuality = c, quality
+		}
+	}
+
+	return best, bestQuality > 0
+}
+
+// IncompressibleTypes lists the prefixes of media types are not compressed as they are compressed already.
+var IncompressibleTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/x-bzip2",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+	"application/pdf",
+	"application/octet-stream",
+	"application/wasm",
+}
+
+// compressible reports whether the content type should be compressed.
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if mediaType == "image/svg+xml" {
+		return true
+	}
+	for _, prefix := range IncompressibleTypes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compressWriter represents a response writer compresses the body.
+// The body is buffered until the minimum size reached for deciding whether to compress.
+type compressWriter struct {
+	http.ResponseWriter
+	coding  coding
+	level   int
+	minSize int
+
+	status  int
+	buffer  []byte
+	decided bool
+	writer  io.WriteCloser // nil if not compressed
+}
+
+// WriteHeader implements http.ResponseWriter interface.
+func (w *compressWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = status
+	if status < 200 || status == http.StatusNoContent || status == http.StatusNotModified {
+		w.decide(false)
+	}
+}
+
+// Write implements http.ResponseWriter interface.
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !w.decided {
+		w.buffer = append(w.buffer, data...)
+		if len(w.buffer) < w.minSize {
+			return len(data), nil
+		}
+		err := w.start()
+		if err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+
+	if w.writer != nil {
+		return w.writer.Write(data)
+	}
+
+	return w.ResponseWriter.Write(data)
+}
+
+// compress reports whether the response should be compressed.
+func (w *compressWriter) compress() bool {
+	header := w.ResponseWriter.Header()
+	if len(header.Get("Content-Encoding")) > 0 || len(header.Get("Content-Range")) > 0 {
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	if len(contentType) == 0 {
+		if len(w.buffer) == 0 { // unknown yet
+			return false
+		}
+		contentType = http.DetectContentType(w.buffer)
+		header.Set("Content-Type", contentType)
+	}
+
+	return compressible(contentType)
+}
+
+// decide writes the header, the body is compressed if enabled.
+func (w *compressWriter) decide(enabled bool) error {
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if enabled {
+		writer, err := w.coding.Compressor(w.ResponseWriter, w.level)
+		if err != nil {
+			return errors.Wrapf(err, "http: could not create %s compressor", w.coding.Name)
+		}
+		w.writer = writer
+		header := w.ResponseWriter.Header()
+		header.Set("Content-Encoding", w.coding.Name)
+		header.Del("Content-Length")
+		header.Del("Accept-Ranges")
+		if etag := header.Get("ETag"); strings.HasSuffix(etag, `"`) && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag) // the strong validator does not match the compressed representation
+		}
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	return nil
+}
+
+// start decides by the buffered data and writes it.
+func (w *compressWriter) start() error {
+	err := w.decide(w.compress())
+	if err != nil {
+		return err
+	}
+
+	data := w.buffer
+	w.buffer = nil
+	if len(data) == 0 {
+		return nil
+	}
+	if w.writer != nil {
+		_, err = w.writer.Write(data)
+	} else {
+		_, err = w.ResponseWriter.Write(data)
+	}
+	if err != nil {
+		return errors.Wrap(err, "http: could not write buffered data to response")
+	}
+
+	return nil
+}
+
+// Flush implements http.Flusher interface.
+// The buffered data is compressed regardless of the minimum size as the response is streaming.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if w.start() != nil {
+			return
+		}
+	}
+	if flusher, ok := w.writer.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker interface.
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("http: response writer does not support hijacking")
+	}
+	w.decided = true // the connection is taken over
+
+	return hijacker.Hijack()
+}
+
+// Close writes the buffered data and finishes the compression.
+func (w *compressWriter) Close() error {
+	if !w.decided {
+		if w.status == 0 { // nothing written
+			w.decided = true
+			return nil
+		}
+		err := w.decide(false) // too small to compress
+		if err != nil {
+			return err
+		}
+		if len(w.buffer) > 0 {
+			_, err = w.ResponseWriter.Write(w.buffer)
+			if err != nil {
+				return errors.Wrap(err, "http: could not write buffered data to response")
+			}
+		}
+	}
+	if w.writer != nil {
+		err := w.writer.Close()
+		if err != nil {
+			return errors.Wrapf(err, "http: could not finish %s compression", w.coding.Name)
+		}
+	}
+
+	return nil
+}
+
+// decompressReader represents a reader of decompressed request body fails after the limit reached.
+type decompressReader struct {
+	io.ReadCloser
+	remaining int64
+}
+
+// Read implements io.Reader interface.
+func (r *decompressReader) Read(p []byte) (int, error) {
+	if r.remaining < 0 {
+		return 0, errDecompressedTooLarge()
+	}
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1] // one more byte to detect the overflow
+	}
+	n, err := r.ReadCloser.Read(p)
+	r.remaining -= int64(n)
+	if r.remaining < 0 {
+		return n + int(r.remaining), errDecompressedTooLarge()
+	}
+
+	return n, err
+}
+
+// errDecompressedTooLarge returns the error of decompressed request body exceeds the limit.
+func errDecompressedTooLarge() error {
+	return http2.NewError(http.StatusRequestEntityTooLarge, "body_too_large", "The decompressed request body is too large.")
+}
+
+// compressOptions represents the options of compress middleware.
+type compressOptions struct {
+	decompressLimit int64
+}
+
+// CompressOption represents an option of compress middleware.
+type CompressOption func(*compressOptions)
+
+// WithDecompressLimit sets the limit of decompressed request body,
+// the limit of body limit middleware (or DefaultBodyLimit if not used) is used if not set.
+func WithDecompressLimit(limit int64) CompressOption {
+	return func(o *compressOptions) {
+		o.decompressLimit = limit
+	}
+}
+
+// NewCompress returns a middleware compresses the response body by the coding negotiated by Accept-Encoding (gzip and deflate built-in, see RegisterCoding).
+// The level is the compression level (e.g. gzip.DefaultCompression), the body smaller than minSize or with incompressible type (see IncompressibleTypes) is not compressed.
+// The request body encoded by a known coding is decompressed transparently, it responds with 400 (Bad Request) if the body is malformed,
+// or 415 (Unsupported Media Type) if the coding is unknown.
+// The reading of decompressed body fails with *http.Error of 413 (Request Entity Too Large) after the limit reached (see WithDecompressLimit),
+// which is responded by http.HandleError.
+func NewCompress(level int, minSize int, options ...CompressOption) http2.Middleware {
+	var opts compressOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return http2.MiddlewareFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			if encoding := req.Header.Get("Content-Encoding"); len(encoding) > 0 && encoding != "identity" {
+				c, ok := lookupCoding(encoding)
+				if !ok || c.Decompressor == nil {
+					http.Error(rw, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+					return
+				}
+				body, err := c.Decompressor(req.Body)
+				if err != nil {
+					http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+					return
+				}
+				defer body.Close()
+				limit := opts.decompressLimit
+				if limit <= 0 {
+					limit = bodyLimit(req)
+				}
+				req.Body = &decompressReader{ReadCloser: body, remaining: limit}
+				req.Header.Del("Content-Encoding")
+				req.Header.Del("Content-Length")
+				req.ContentLength = -1
+			}
+
+			rw.Header().Add("Vary", "Accept-Encoding")
+			c, ok := negotiateCoding(req.Header.Get("Accept-Encoding"))
+			if !ok || req.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(rw, req)
+				return
+			}
+
+			w := &compressWriter{
+				ResponseWriter: rw,
+				coding:         c,
+				level:          level,
+				minSize:        minSize,
+			}
+			defer w.Close()
+			next.ServeHTTP(w, req)
+		})
+	})
+}
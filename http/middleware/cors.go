@@ -0,0 +1,254 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wayn3h0/gop/errors"
+	http2 "github.com/wayn3h0/gop/http"
+)
+
+// cors represents the configuration of CORS (Cross-Origin Resource Sharing).
+type cors struct {
+	all         bool     // all origins are allowed
+	origins     []string // exact origins
+	wildcards   [][2]string
+	patterns    []string
+	regexps     []*regexp.Regexp
+	methods     []string
+	headers     []string
+	exposed     []string
+	credentials bool
+	maxAge      time.Duration
+}
+
+// CORSOption represents an option of CORS middleware.
+type CORSOption func(*cors)
+
+// AllowOrigins sets the allowed origins, "*" allows all and one wildcard is supported in origin (e.g. "https://*.example.com").
+// All origins are allowed if neither origins nor regular expressions are set, they cannot be combined with credentials.
+func AllowOrigins(origins ...string) CORSOption {
+	return func(c *cors) {
+		for _, origin := range origins {
+			origin = strings.ToLower(origin)
+			if origin == "*" {
+				c.all = true
+			} else if i := strings.IndexByte(origin, '*'); i >= 0 {
+				c.wildcards = append(c.wildcards, [2]string{origin[:i], origin[i+1:]})
+			} else {
+				c.origins = append(c.origins, origin)
+			}
+		}
+	}
+}
+
+// AllowOriginRegexp sets the regular expressions of allowed origins (e.g. `^https://[a-z]+\.example\.com$`).
+func AllowOriginRegexp(patterns ...string) CORSOption {
+	return func(c *cors) {
+		c.patterns = append(c.patterns, patterns...)
+	}
+}
+
+// AllowMethods sets the allowed methods of preflight, the methods registered for the path (Allow header) are used if not set.
+func AllowMethods(methods ...string) CORSOption {
+	return func(c *cors) {
+		for _, method := range methods {
+			c.methods = append(c.methods, strings.ToUpper(method))
+		}
+	}
+}
+
+// AllowHeaders sets the allowed request headers of preflight, the requested headers are allowed if not set.
+func AllowHeaders(headers ...string) CORSOption {
+	return func(c *cors) {
+		for _, header := range headers {
+			c.headers = append(c.headers, http.CanonicalHeaderKey(header))
+		}
+	}
+}
+
+// ExposeHeaders sets the response headers exposed to client.
+func ExposeHeaders(headers ...string) CORSOption {
+	return func(c *cors) {
+		c.exposed = append(c.exposed, headers...)
+	}
+}
+
+// AllowCredentials sets whether the credentials (cookies, authorization headers or TLS client certificates) are allowed.
+// The allowed origins must be set explicitly, since the credentialed responses would be readable by any site otherwise.
+func AllowCredentials(allowed bool) CORSOption {
+	return func(c *cors) {
+		c.credentials = allowed
+	}
+}
+
+// MaxAge sets how long the result of preflight can be cached.
+func MaxAge(d time.Duration) CORSOption {
+	return func(c *cors) {
+		c.maxAge = d
+	}
+}
+
+// allowed reports whether the origin is allowed.
+func (c *cors) allowed(origin string) bool {
+	if c.all {
+		return true
+	}
+
+	lower := strings.ToLower(origin)
+	for _, o := range c.origins {
+		if o == lower {
+			return true
+		}
+	}
+	for _, w := range c.wildcards {
+		if len(lower) >= len(w[0])+len(w[1]) && strings.HasPrefix(lower, w[0]) && strings.HasSuffix(lower, w[1]) {
+			return true
+		}
+	}
+	for _, r := range c.regexps {
+		if r.MatchString(origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowOrigin sets the Access-Control-Allow-Origin and Access-Control-Allow-Credentials headers.
+// The origin is echoed only if it is allowed explicitly.
+func (c *cors) allowOrigin(header http.Header, origin string) {
+	if c.all {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+		header.Add("Vary", "Origin")
+	}
+	if c.credentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// preflightWriter represents a response writer adds the CORS headers to the successful response of preflight request.
+// The status is kept, so the body written by the handler registered for OPTIONS is not dropped (the automatic answer of TreeMux is 204 already).
+type preflightWriter struct {
+	http.ResponseWriter
+	cors    *cors
+	request *http.Request
+	written bool
+}
+
+// WriteHeader implements http.ResponseWriter interface.
+func (w *preflightWriter) WriteHeader(status int) {
+	if w.written {
+		return
+	}
+	w.written = true
+
+	if status >= 200 && status < 300 {
+		header := w.ResponseWriter.Header()
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+
+		methods := w.cors.methods
+		if len(methods) == 0 {
+			for _, method := range strings.Split(header.Get("Allow"), ",") {
+				if method = strings.TrimSpace(method); len(method) > 0 {
+					methods = append(methods, method)
+				}
+			}
+		}
+		requested := w.request.Header.Get("Access-Control-Request-Method")
+		ok := len(methods) == 0 // unknown, leave it to the browser
+		for _, method := range methods {
+			ok = ok || method == requested
+		}
+
+		if ok {
+			w.cors.allowOrigin(header, w.request.Header.Get("Origin"))
+			if len(methods) > 0 {
+				header.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+			}
+			if len(w.cors.headers) > 0 {
+				header.Set("Access-Control-Allow-Headers", strings.Join(w.cors.headers, ", "))
+			} else if headers := w.request.Header.Get("Access-Control-Request-Headers"); len(headers) > 0 {
+				header.Set("Access-Control-Allow-Headers", headers)
+			}
+			if w.cors.maxAge > 0 {
+				header.Set("Access-Control-Max-Age", strconv.Itoa(int(w.cors.maxAge/time.Second)))
+			}
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter interface.
+func (w *preflightWriter) Write(data []byte) (int, error) {
+	if !w.written {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(data)
+}
+
+// NewCORS returns a middleware handles CORS (Cross-Origin Resource Sharing) requests.
+//
+// It can wrap the router (e.g. http.ListenAndServe(addr, cors.Wrap(router))) or be used by Router.Use,
+// the preflight requests are answered by the automatic OPTIONS response of TreeMux, which is wrapped by the middlewares of Router too.
+// When it's used by Router.Use, it only applies to the routes registered after it (the preflight of a path follows its first registered route).
+// The preflight request of an unregistered path is responded as usual (e.g. 404), the handler registered for OPTIONS is still called.
+// It returns an error if the credentials are allowed for all origins.
+func NewCORS(options ...CORSOption) (http2.Middleware, error) {
+	c := &cors{}
+	for _, option := range options {
+		option(c)
+	}
+	for _, pattern := range c.patterns {
+		r, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "http: could not compile origin regexp %q", pattern)
+		}
+		c.regexps = append(c.regexps, r)
+	}
+	if len(c.origins) == 0 && len(c.wildcards) == 0 && len(c.regexps) == 0 {
+		c.all = true
+	}
+	if c.all && c.credentials {
+		return nil, errors.New("http: credentials of CORS cannot be allowed for all origins")
+	}
+
+	return http2.MiddlewareFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			origin := req.Header.Get("Origin")
+			if len(origin) == 0 {
+				next.ServeHTTP(rw, req)
+				return
+			}
+			if !c.allowed(origin) {
+				rw.Header().Add("Vary", "Origin")
+				next.ServeHTTP(rw, req)
+				return
+			}
+
+			if req.Method == "OPTIONS" && len(req.Header.Get("Access-Control-Request-Method")) > 0 {
+				w := &preflightWriter{ResponseWriter: rw, cors: c, request: req}
+				next.ServeHTTP(w, req)
+				if !w.written {
+					w.WriteHeader(http.StatusOK)
+				}
+				return
+			}
+
+			header := rw.Header()
+			c.allowOrigin(header, origin)
+			if len(c.exposed) > 0 {
+				header.Set("Access-Control-Expose-Headers", strings.Join(c.exposed, ", "))
+			}
+			next.ServeHTTP(rw, req)
+		})
+	}), nil
+}
@@ -0,0 +1,12 @@
+/*
+
+Package middleware providers the common middlewares for http.Router.
+
+The middlewares are composable through Router.Use, the first used one is the outermost:
+
+	router.Use(middleware.NewRecovery(logger, false))
+	router.Use(middleware.NewRequestID(""))
+	router.Use(middleware.NewAccessLog(logger))
+
+*/
+package middleware
@@ -0,0 +1,257 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wayn3h0/gop/errors"
+	"github.com/wayn3h0/gop/hash"
+	http2 "github.com/wayn3h0/gop/http"
+	"github.com/wayn3h0/gop/uuid"
+)
+
+// The headers of HMAC signed request.
+const (
+	TimestampHeader = "X-Timestamp" // unix time in seconds
+	NonceHeader     = "X-Nonce"     // unique string of request
+)
+
+// DefaultHMACSkew is the default maximum difference between the timestamp of request and the current time.
+const DefaultHMACSkew = 5 * time.Minute
+
+// stringToSign returns the string to sign of request:
+// method, host, request URI, content type, timestamp, nonce and hex encoded SHA-256 of body, separated by new lines.
+func stringToSign(req *http.Request, body []byte) []byte {
+	host := req.Host
+	if len(host) == 0 { // outgoing request
+		host = req.URL.Host
+	}
+	sum := sha256.Sum256(body)
+	return []byte(strings.Join([]string{
+		req.Method,
+		strings.ToLower(host),
+		req.URL.RequestURI(),
+		req.Header.Get("Content-Type"),
+		req.Header.Get(TimestampHeader),
+		req.Header.Get(NonceHeader),
+		hex.EncodeToString(sum[:]),
+	}, "\n"))
+}
+
+// errBodyTooLarge is returned by readBody if the body exceeds the limit.
+var errBodyTooLarge = errors.New("http: request body is too large")
+
+// readBody reads the body of request up to the limit (no limit if not positive) and restores it for reading again.
+func readBody(req *http.Request, limit int64) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	if limit > 0 && req.ContentLength > limit {
+		return nil, errBodyTooLarge
+	}
+	r := io.Reader(req.Body)
+	if limit > 0 {
+		r = io.LimitReader(req.Body, limit+1) // one more byte to detect the overflow
+	}
+	body, err := ioutil.ReadAll(r)
+	if limit > 0 && int64(len(body)) > limit {
+		return nil, errBodyTooLarge
+	}
+	if err != nil {
+		if limit > 0 && int64(len(body)) == limit { // failed by the body limit middleware
+			return nil, errBodyTooLarge
+		}
+		return nil, errors.Wrap(err, "http: could not read request body")
+	}
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+// SignRequest signs the request by HMAC-SHA256 with the key, it sets the timestamp, nonce and Authorization headers:
+//
+//	Authorization: HMAC-SHA256 KeyId=<key id>, Signature=<base64 signature>
+func SignRequest(req *http.Request, keyID string, key []byte) error {
+	body, err := readBody(req, 0)
+	if err != nil {
+		return err
+	}
+
+	nonce, err := uuid.New()
+	if err != nil {
+		return errors.Wrap(err, "http: could not generate nonce of request")
+	}
+
+	req.Header.Set(TimestampHeader, strconv.FormatInt(time.Now().Unix(), 10))
+	req.Header.Set(NonceHeader, nonce.String())
+	signature, err := hash.Compute(hmac.New(sha256.New, key), stringToSign(req, body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "HMAC-SHA256 KeyId="+keyID+", Signature="+base64.StdEncoding.EncodeToString(signature))
+
+	return nil
+}
+
+// NonceStore represents a store of used nonces for replay protection.
+type NonceStore interface {
+	// Add adds the nonce with time to live, it reports whether the nonce is new.
+	Add(nonce string, ttl time.Duration) (bool, error)
+}
+
+// memoryNonceStore represents an in-memory nonce store.
+type memoryNonceStore struct {
+	mutex  sync.Mutex
+	nonces map[string]time.Time
+	adds   int
+}
+
+// Add implements NonceStore interface.
+func (s *memoryNonceStore) Add(nonce string, ttl time.Duration) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	if expiration, ok := s.nonces[nonce]; ok && now.Before(expiration) {
+		return false, nil
+	}
+	s.nonces[nonce] = now.Add(ttl)
+
+	// sweeps expired nonces periodically
+	s.adds++
+	if s.adds%1024 == 0 {
+		for n, expiration := range s.nonces {
+			if !now.Before(expiration) {
+				delete(s.nonces, n)
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// NewMemoryNonceStore returns a new in-memory nonce store.
+func NewMemoryNonceStore() NonceStore {
+	return &memoryNonceStore{
+		nonces: make(map[string]time.Time),
+	}
+}
+
+// HMACKeyFunc represents a func returns the key by key id, it returns nil if not found.
+type HMACKeyFunc func(keyID string) ([]byte, error)
+
+// parseHMAC parses the Authorization header of HMAC signed request.
+func parseHMAC(authorization string) (string, []byte, bool) {
+	const scheme = "HMAC-SHA256 "
+	if !strings.HasPrefix(authorization, scheme) {
+		return "", nil, false
+	}
+
+	var keyID string
+	var signature []byte
+	for _, item := range strings.Split(authorization[len(scheme):], ",") {
+		item = strings.TrimSpace(item)
+		switch {
+		case strings.HasPrefix(item, "KeyId="):
+			keyID = item[len("KeyId="):]
+		case strings.HasPrefix(item, "Signature="):
+			var err error
+			signature, err = base64.StdEncoding.DecodeString(item[len("Signature="):])
+			if err != nil {
+				return "", nil, false
+			}
+		}
+	}
+
+	return keyID, signature, len(keyID) > 0 && len(signature) > 0
+}
+
+// NewHMACAuth returns a middleware authenticates the request signed by SignRequest, the key is looked up by the key id.
+// The timestamp must be within the skew (DefaultHMACSkew if not positive) and the nonce cannot be reused within the skew,
+// the nonces are kept in the store (a new in-memory store if nil).
+// The body is buffered for verifying the signature, up to the limit of body limit middleware (or DefaultBodyLimit if not used).
+// It responds with 401 (Unauthorized) if the signature is missing or invalid, 413 (Request Entity Too Large) if the body exceeds the limit,
+// or 500 (Internal Server Error) if the key or nonce cannot be checked.
+func NewHMACAuth(lookup HMACKeyFunc, skew time.Duration, nonces NonceStore) http2.Middleware {
+	if skew <= 0 {
+		skew = DefaultHMACSkew
+	}
+	if nonces == nil {
+		nonces = NewMemoryNonceStore()
+	}
+
+	return http2.MiddlewareFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			keyID, signature, ok := parseHMAC(req.Header.Get("Authorization"))
+			if !ok {
+				unauthorized(rw, "HMAC-SHA256")
+				return
+			}
+			timestamp, err := strconv.ParseInt(req.Header.Get(TimestampHeader), 10, 64)
+			if err != nil {
+				unauthorized(rw, "HMAC-SHA256")
+				return
+			}
+			if d := time.Since(time.Unix(timestamp, 0)); d > skew || d < -skew {
+				unauthorized(rw, "HMAC-SHA256")
+				return
+			}
+			nonce := req.Header.Get(NonceHeader)
+			if len(nonce) == 0 {
+				unauthorized(rw, "HMAC-SHA256")
+				return
+			}
+
+			key, err := lookup(keyID)
+			if err != nil {
+				http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			if len(key) == 0 {
+				unauthorized(rw, "HMAC-SHA256")
+				return
+			}
+			body, err := readBody(req, bodyLimit(req))
+			if err == errBodyTooLarge {
+				http.Error(rw, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if err != nil {
+				http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+			ok, err = hash.Verify(hmac.New(sha256.New, key), signature, stringToSign(req, body))
+			if err != nil || !ok {
+				unauthorized(rw, "HMAC-SHA256")
+				return
+			}
+
+			// the nonce is recorded only for the valid signature, so it cannot be burned by forged requests
+			ok, err = nonces.Add(keyID+":"+nonce, 2*skew)
+			if err != nil {
+				http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			if !ok {
+				unauthorized(rw, "HMAC-SHA256")
+				return
+			}
+
+			next.ServeHTTP(rw, withPrincipal(req, &Principal{
+				Name:   keyID,
+				Scheme: "HMAC",
+			}))
+		})
+	})
+}
@@ -0,0 +1,379 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wayn3h0/gop/errors"
+	"github.com/wayn3h0/gop/hash"
+	http2 "github.com/wayn3h0/gop/http"
+	"github.com/wayn3h0/gop/log"
+)
+
+// KeySet represents a set of keys for verifying JWT, the keys can be added and removed concurrently for rotation.
+// The key is []byte for HS256, *rsa.PublicKey for RS256, or *ecdsa.PublicKey (P-256) for ES256.
+type KeySet struct {
+	mutex sync.RWMutex
+	keys  map[string]interface{}
+}
+
+// Add adds the key with id (the "kid" header of JWT), it replaces the one with same id.
+func (s *KeySet) Add(id string, key interface{}) error {
+	switch k := key.(type) {
+	case []byte:
+		if len(k) == 0 {
+			return errors.New("http: HMAC key of JWT cannot be empty")
+		}
+	case *rsa.PublicKey:
+	case *ecdsa.PublicKey:
+		if k.Curve.Params().BitSize != 256 {
+			return errors.New("http: ECDSA key of JWT must be P-256")
+		}
+	default:
+		return errors.Newf("http: type %T of JWT key is not supported", key)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.keys[id] = key
+
+	return nil
+}
+
+// Remove removes the key by id.
+func (s *KeySet) Remove(id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.keys, id)
+}
+
+// Get returns the key by id, the only key is returned for empty id.
+func (s *KeySet) Get(id string) (interface{}, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if len(id) == 0 && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[id]
+
+	return key, ok
+}
+
+// NewKeySet returns a new empty key set.
+func NewKeySet() *KeySet {
+	return &KeySet{
+		keys: make(map[string]interface{}),
+	}
+}
+
+// jwtHeader represents the header of JWT.
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+	KeyID     string `json:"kid,omitempty"`
+}
+
+// JWTVerifier represents a verifier of JWT (JSON Web Token).
+type JWTVerifier struct {
+	keys     *KeySet
+	audience string
+	issuer   string
+	leeway   time.Duration
+	now      func() time.Time
+	logger   *log.Logger
+}
+
+// JWTOption represents an option of JWT verifier.
+type JWTOption func(*JWTVerifier)
+
+// WithAudience requires the audience ("aud" claim) contains the audience.
+func WithAudience(audience string) JWTOption {
+	return func(v *JWTVerifier) {
+		v.audience = audience
+	}
+}
+
+// WithIssuer requires the issuer ("iss" claim) equals to the issuer.
+func WithIssuer(issuer string) JWTOption {
+	return func(v *JWTVerifier) {
+		v.issuer = issuer
+	}
+}
+
+// WithLeeway sets the leeway of checking "exp" and "nbf" claims for clock skew.
+func WithLeeway(leeway time.Duration) JWTOption {
+	return func(v *JWTVerifier) {
+		v.leeway = leeway
+	}
+}
+
+// WithJWTLogger sets the logger for the detailed errors of rejected tokens, log.DefaultLogger used if not set.
+func WithJWTLogger(logger *log.Logger) JWTOption {
+	return func(v *JWTVerifier) {
+		v.logger = logger
+	}
+}
+
+// NewJWTVerifier returns a new JWT verifier with the key set.
+func NewJWTVerifier(keys *KeySet, options ...JWTOption) *JWTVerifier {
+	v := &JWTVerifier{
+		keys:   keys,
+		now:    time.Now,
+		logger: log.DefaultLogger,
+	}
+	for _, option := range options {
+		option(v)
+	}
+
+	return v
+}
+
+// The public descriptions of invalid JWT.
+const (
+	jwtMalformed = "malformed token"
+	jwtSignature = "signature invalid"
+	jwtExpired   = "token expired"
+	jwtNotYet    = "token not valid yet"
+	jwtClaims    = "claims invalid"
+)
+
+// JWTError represents an error of invalid JWT.
+// The description is public for the client, the cause is internal as it has the details (e.g. source locations).
+type JWTError struct {
+	Description string
+	Cause       error
+}
+
+// Error implements builtin.error interface.
+func (e *JWTError) Error() string {
+	return e.Cause.Error()
+}
+
+// Unwrap returns the cause.
+func (e *JWTError) Unwrap() error {
+	return e.Cause
+}
+
+// invalidJWT returns the error of invalid JWT with public description.
+func invalidJWT(description string, cause error) error {
+	return &JWTError{
+		Description: description,
+		Cause:       cause,
+	}
+}
+
+// numericDate returns the time of numeric date claim.
+func numericDate(claims map[string]interface{}, name string) (time.Time, bool, error) {
+	v, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	n, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false, errors.Newf("http: claim %q of JWT must be a number", name)
+	}
+
+	return time.Unix(0, int64(n*float64(time.Second))), true, nil
+}
+
+// Verify verifies the signature and the claims of token, it returns the claims, or *JWTError if the token is invalid.
+func (v *JWTVerifier) Verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, invalidJWT(jwtMalformed, errors.New("http: JWT is malformed"))
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, invalidJWT(jwtMalformed, errors.Wrap(err, "http: could not decode JWT header"))
+	}
+	var header jwtHeader
+	err = json.Unmarshal(data, &header)
+	if err != nil {
+		return nil, invalidJWT(jwtMalformed, errors.Wrap(err, "http: could not unmarshal JWT header"))
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, invalidJWT(jwtMalformed, errors.Wrap(err, "http: could not decode JWT signature"))
+	}
+
+	key, ok := v.keys.Get(header.KeyID)
+	if !ok {
+		return nil, invalidJWT(jwtSignature, errors.Newf("http: key %q of JWT is unknown", header.KeyID))
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	digest := sha256.Sum256(signed)
+	switch k := key.(type) { // the algorithm must match the type of key
+	case []byte:
+		if header.Algorithm != "HS256" {
+			return nil, invalidJWT(jwtSignature, errors.Newf("http: algorithm %q of JWT does not match the HMAC key", header.Algorithm))
+		}
+		ok, err = hash.Verify(hmac.New(sha256.New, k), signature, signed)
+		if err != nil {
+			return nil, invalidJWT(jwtSignature, err)
+		}
+	case *rsa.PublicKey:
+		if header.Algorithm != "RS256" {
+			return nil, invalidJWT(jwtSignature, errors.Newf("http: algorithm %q of JWT does not match the RSA key", header.Algorithm))
+		}
+		ok = rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil
+	case *ecdsa.PublicKey:
+		if header.Algorithm != "ES256" {
+			return nil, invalidJWT(jwtSignature, errors.Newf("http: algorithm %q of JWT does not match the ECDSA key", header.Algorithm))
+		}
+		ok = len(signature) == 64 && ecdsa.Verify(k, digest[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:]))
+	}
+	if !ok {
+		return nil, invalidJWT(jwtSignature, errors.New("http: signature of JWT is invalid"))
+	}
+
+	data, err = base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, invalidJWT(jwtMalformed, errors.Wrap(err, "http: could not decode JWT claims"))
+	}
+	var claims map[string]interface{}
+	err = json.Unmarshal(data, &claims)
+	if err != nil {
+		return nil, invalidJWT(jwtMalformed, errors.Wrap(err, "http: could not unmarshal JWT claims"))
+	}
+
+	now := v.now()
+	exp, ok, err := numericDate(claims, "exp")
+	if err != nil {
+		return nil, invalidJWT(jwtMalformed, err)
+	}
+	if ok && !now.Before(exp.Add(v.leeway)) {
+		return nil, invalidJWT(jwtExpired, errors.New("http: JWT has expired"))
+	}
+	nbf, ok, err := numericDate(claims, "nbf")
+	if err != nil {
+		return nil, invalidJWT(jwtMalformed, err)
+	}
+	if ok && now.Before(nbf.Add(-v.leeway)) {
+		return nil, invalidJWT(jwtNotYet, errors.New("http: JWT is not valid yet"))
+	}
+	if len(v.issuer) > 0 {
+		if iss, _ := claims["iss"].(string); iss != v.issuer {
+			return nil, invalidJWT(jwtClaims, errors.Newf("http: issuer %q of JWT is invalid", iss))
+		}
+	}
+	if len(v.audience) > 0 {
+		found := false
+		switch aud := claims["aud"].(type) {
+		case string:
+			found = aud == v.audience
+		case []interface{}:
+			for _, a := range aud {
+				found = found || a == v.audience
+			}
+		}
+		if !found {
+			return nil, invalidJWT(jwtClaims, errors.Newf("http: JWT is not intended for audience %q", v.audience))
+		}
+	}
+
+	return claims, nil
+}
+
+// SignJWT signs the claims as JWT with key id, the algorithm is determined by the key:
+// []byte for HS256, *rsa.PrivateKey for RS256, or *ecdsa.PrivateKey (P-256) for ES256.
+func SignJWT(claims map[string]interface{}, keyID string, key interface{}) (string, error) {
+	header := jwtHeader{
+		Type:  "JWT",
+		KeyID: keyID,
+	}
+	switch key.(type) {
+	case []byte:
+		header.Algorithm = "HS256"
+	case *rsa.PrivateKey:
+		header.Algorithm = "RS256"
+	case *ecdsa.PrivateKey:
+		header.Algorithm = "ES256"
+	default:
+		return "", errors.Newf("http: type %T of JWT key is not supported", key)
+	}
+
+	data, err := json.Marshal(header)
+	if err != nil {
+		return "", errors.Wrap(err, "http: could not marshal JWT header")
+	}
+	token := base64.RawURLEncoding.EncodeToString(data)
+	data, err = json.Marshal(claims)
+	if err != nil {
+		return "", errors.Wrap(err, "http: could not marshal JWT claims")
+	}
+	token += "." + base64.RawURLEncoding.EncodeToString(data)
+
+	var signature []byte
+	digest := sha256.Sum256([]byte(token))
+	switch k := key.(type) {
+	case []byte:
+		signature, err = hash.Compute(hmac.New(sha256.New, k), []byte(token))
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, digest[:])
+		if err == nil {
+			signature = make([]byte, 64)
+			r.FillBytes(signature[:32])
+			s.FillBytes(signature[32:])
+		}
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "http: could not sign JWT")
+	}
+
+	return token + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// NewJWTAuth returns a middleware authenticates the request by the JWT in Authorization header (Bearer scheme).
+// The principal is named by the subject ("sub" claim) and has the claims.
+// It responds with 401 (Unauthorized) and WWW-Authenticate header if the token is missing or invalid,
+// the header describes the class of failure only (e.g. "token expired"), the details are logged by the logger of verifier.
+func NewJWTAuth(verifier *JWTVerifier) http2.Middleware {
+	return http2.MiddlewareFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			authorization := req.Header.Get("Authorization")
+			if len(authorization) < 7 || !strings.EqualFold(authorization[:7], "Bearer ") {
+				unauthorized(rw, "Bearer")
+				return
+			}
+			claims, err := verifier.Verify(strings.TrimSpace(authorization[7:]))
+			if err != nil {
+				description := jwtSignature
+				if e, ok := err.(*JWTError); ok {
+					description = e.Description
+				}
+				if verifier.logger != nil {
+					verifier.logger.Debugf("http: JWT of %s %s is rejected: %s", req.Method, req.URL.Path, errors.TreeMessage(err))
+				}
+				unauthorized(rw, `Bearer error="invalid_token", error_description=`+strconv.Quote(description))
+				return
+			}
+
+			subject, _ := claims["sub"].(string)
+			next.ServeHTTP(rw, withPrincipal(req, &Principal{
+				Name:   subject,
+				Scheme: "Bearer",
+				Claims: claims,
+			}))
+		})
+	})
+}
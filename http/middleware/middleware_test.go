@@ -0,0 +1,834 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wayn3h0/gop/cache"
+	"github.com/wayn3h0/gop/cache/container/memory/lru"
+	"github.com/wayn3h0/gop/errors"
+	http2 "github.com/wayn3h0/gop/http"
+	"github.com/wayn3h0/gop/log"
+	testing2 "github.com/wayn3h0/gop/testing"
+	"github.com/wayn3h0/gop/uuid"
+)
+
+// serve serves the request by handler wrapped with middlewares.
+func serve(handler http.HandlerFunc, req *http.Request, middlewares ...http2.Middleware) *httptest.ResponseRecorder {
+	var h http.Handler = handler
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i].Wrap(h)
+	}
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	return rw
+}
+
+func TestRequestID(t *testing.T) {
+	var id string
+	handler := func(rw http.ResponseWriter, req *http.Request) {
+		id = RequestID(req)
+	}
+
+	rw := serve(handler, httptest.NewRequest("GET", "/", nil), NewRequestID(""))
+	testing2.ExpectEqual(t, uuid.IsValid(id), true)
+	testing2.ExpectEqual(t, rw.Header().Get(DefaultRequestIDHeader), id)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Trace-ID", "trace")
+	rw = serve(handler, req, NewRequestID("X-Trace-ID"))
+	testing2.ExpectEqual(t, id, "trace")
+	testing2.ExpectEqual(t, rw.Header().Get("X-Trace-ID"), "trace")
+
+	for i, invalid := range []string{strings.Repeat("x", 129), "a b", "id\x00", "标识"} {
+		req = httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(DefaultRequestIDHeader, invalid)
+		serve(handler, req, NewRequestID(""))
+		testing2.ExpectEqualL(t, uuid.IsValid(id), true, i)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewLogger(&buf, "")
+	handler := func(rw http.ResponseWriter, req *http.Request) {
+		panic(errors.New("boom"))
+	}
+
+	rw := serve(handler, httptest.NewRequest("GET", "/", nil), NewRecovery(logger, false))
+	testing2.ExpectEqual(t, rw.Code, http.StatusInternalServerError)
+	testing2.ExpectEqual(t, rw.Body.String(), http.StatusText(http.StatusInternalServerError))
+	testing2.ExpectEqual(t, strings.Contains(buf.String(), "boom"), true)
+
+	rw = serve(handler, httptest.NewRequest("GET", "/", nil), NewRecovery(logger, true))
+	testing2.ExpectEqual(t, strings.Contains(rw.Body.String(), "├─ http: panic recovered in handling GET /"), true)
+	testing2.ExpectEqual(t, strings.Contains(rw.Body.String(), "boom"), true)
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewLogger(&buf, "")
+	handler := func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusCreated)
+		rw.Write([]byte("created"))
+	}
+
+	req := httptest.NewRequest("POST", "/users?x=1", nil)
+	req.Header.Set(DefaultRequestIDHeader, "id")
+	serve(handler, req, NewRequestID(""), NewAccessLog(logger))
+	testing2.ExpectEqual(t, strings.Contains(buf.String(), `192.0.2.1 POST "/users?x=1" HTTP/1.1 201 7 `), true)
+	testing2.ExpectEqual(t, strings.HasSuffix(buf.String(), ` "id"`+"\n"), true)
+
+	// the values from client are quoted
+	buf.Reset()
+	req = httptest.NewRequest("GET", "/", nil)
+	req.RequestURI = "/a\n1.2.3.4 GET /admin"
+	req.Header.Set("User-Agent", "agent\n")
+	serve(handler, req, NewAccessLog(logger))
+	testing2.ExpectEqual(t, strings.Count(buf.String(), "\n"), 1)
+	testing2.ExpectEqual(t, strings.Contains(buf.String(), `"/a\n1.2.3.4 GET /admin"`), true)
+
+	// the underlying writer is reachable
+	var w http.ResponseWriter
+	rw := serve(func(rw http.ResponseWriter, req *http.Request) {
+		w = rw
+		io.Copy(rw, strings.NewReader("body"))
+	}, httptest.NewRequest("GET", "/", nil), NewAccessLog(logger))
+	_, ok := w.(io.ReaderFrom)
+	testing2.ExpectEqual(t, ok, true)
+	testing2.ExpectEqual(t, w.(interface{ Unwrap() http.ResponseWriter }).Unwrap(), http.ResponseWriter(rw))
+	testing2.ExpectEqual(t, rw.Body.String(), "body")
+	testing2.ExpectEqual(t, strings.Contains(buf.String(), " 200 4 "), true)
+}
+
+func TestRealIP(t *testing.T) {
+	_, err := NewRealIP("10.0.0.0/33")
+	testing2.ExpectNotEqual(t, err, nil)
+
+	m, err := NewRealIP("10.0.0.0/8", "::1")
+	testing2.AssertEqual(t, err, nil)
+
+	var ip string
+	handler := func(rw http.ResponseWriter, req *http.Request) {
+		ip = RealIP(req)
+	}
+	addresses := map[string]string{
+		"10.1.2.3:1234":  "203.0.113.9", // trusted
+		"[::1]:1234":     "203.0.113.9", // trusted
+		"192.0.2.1:1234": "192.0.2.1",   // untrusted
+	}
+	for addr, want := range addresses {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = addr
+		req.Header.Set("X-Forwarded-For", "203.0.113.9, 10.1.2.3")
+		serve(handler, req, m)
+		testing2.ExpectEqual(t, ip, want)
+	}
+
+	headers := []struct {
+		forwardedFor string
+		realIP       string
+		want         string
+	}{
+		{"198.51.100.7, 203.0.113.9, 10.1.2.3", "", "203.0.113.9"}, // forged leftmost entry
+		{"10.9.9.9, 10.1.2.3", "", "10.9.9.9"},                     // all hops are trusted
+		{"203.0.113.9, bogus, 10.1.2.3", "", "10.1.2.3"},           // malformed hop
+		{"", "203.0.113.9", "203.0.113.9"},
+		{"", "not an ip", "10.1.2.3"},
+	}
+	for i, h := range headers {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "10.1.2.3:1234"
+		if len(h.forwardedFor) > 0 {
+			req.Header.Set("X-Forwarded-For", h.forwardedFor)
+		}
+		if len(h.realIP) > 0 {
+			req.Header.Set("X-Real-IP", h.realIP)
+		}
+		serve(handler, req, m)
+		testing2.ExpectEqualL(t, ip, h.want, i)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	handler := func(rw http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(time.Second):
+			rw.Write([]byte("done"))
+		}
+	}
+
+	rw := serve(handler, httptest.NewRequest("GET", "/", nil), NewTimeout(10*time.Millisecond, "timeout"))
+	testing2.ExpectEqual(t, rw.Code, http.StatusServiceUnavailable)
+	testing2.ExpectEqual(t, rw.Body.String(), "timeout")
+
+	rw = serve(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("X-Done", "true")
+		rw.WriteHeader(http.StatusCreated)
+		rw.Write([]byte("done"))
+	}, httptest.NewRequest("GET", "/", nil), NewTimeout(time.Second, "timeout"))
+	testing2.ExpectEqual(t, rw.Code, http.StatusCreated)
+	testing2.ExpectEqual(t, rw.Header().Get("X-Done"), "true")
+	testing2.ExpectEqual(t, rw.Body.String(), "done")
+
+	// the streaming response is written through after flushed
+	errs := make(chan error, 1)
+	rw = serve(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("event"))
+		rw.(http.Flusher).Flush()
+		<-req.Context().Done()
+		_, err := rw.Write([]byte("late"))
+		errs <- err
+	}, httptest.NewRequest("GET", "/", nil), NewTimeout(10*time.Millisecond, "timeout"))
+	testing2.ExpectEqual(t, rw.Code, http.StatusOK)
+	testing2.ExpectEqual(t, rw.Flushed, true)
+	testing2.ExpectEqual(t, <-errs, http.ErrHandlerTimeout)
+	testing2.ExpectEqual(t, rw.Body.String(), "event")
+}
+
+func TestBodyLimit(t *testing.T) {
+	var readErr error
+	handler := func(rw http.ResponseWriter, req *http.Request) {
+		_, readErr = ioutil.ReadAll(req.Body)
+	}
+
+	rw := serve(handler, httptest.NewRequest("POST", "/", strings.NewReader("0123456789")), NewBodyLimit(5))
+	testing2.ExpectEqual(t, rw.Code, http.StatusRequestEntityTooLarge)
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader("0123456789"))
+	req.ContentLength = -1 // unknown
+	serve(handler, req, NewBodyLimit(5))
+	testing2.ExpectNotEqual(t, readErr, nil)
+
+	serve(handler, httptest.NewRequest("POST", "/", strings.NewReader("01234")), NewBodyLimit(5))
+	testing2.ExpectEqual(t, readErr, nil)
+}
+
+func TestCompress(t *testing.T) {
+	text := strings.Repeat("hello world ", 200)
+	handler := func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/text":
+			rw.Write([]byte(text))
+		case "/small":
+			rw.Write([]byte("hi"))
+		case "/image":
+			rw.Header().Set("Content-Type", "image/png")
+			rw.Write([]byte(text))
+		case "/stream":
+			rw.Header().Set("Content-Type", "text/event-stream")
+			rw.Write([]byte("data: 1\n\n"))
+			rw.(http.Flusher).Flush()
+			rw.Write([]byte("data: 2\n\n"))
+		case "/echo":
+			body, _ := ioutil.ReadAll(req.Body)
+			rw.Write(body)
+		case "/upload":
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				http2.HandleError(rw, req, err)
+				return
+			}
+			fmt.Fprint(rw, len(body))
+		}
+	}
+	m := NewCompress(gzip.DefaultCompression, DefaultCompressMinSize)
+	get := func(path string, encoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Encoding", encoding)
+		return serve(handler, req, m)
+	}
+	decompress := func(rw *httptest.ResponseRecorder) string {
+		r, err := gzip.NewReader(rw.Body)
+		testing2.AssertEqual(t, err, nil)
+		data, err := ioutil.ReadAll(r)
+		testing2.AssertEqual(t, err, nil)
+		return string(data)
+	}
+
+	rw := get("/text", "deflate;q=0.5, gzip")
+	testing2.ExpectEqual(t, rw.Header().Get("Content-Encoding"), "gzip")
+	testing2.ExpectEqual(t, rw.Header().Get("Vary"), "Accept-Encoding")
+	testing2.ExpectEqual(t, rw.Header().Get("Content-Type"), "text/plain; charset=utf-8")
+	testing2.ExpectEqual(t, decompress(rw), text)
+
+	rw = get("/text", "deflate")
+	testing2.ExpectEqual(t, rw.Header().Get("Content-Encoding"), "deflate")
+	r, err := zlib.NewReader(rw.Body)
+	testing2.AssertEqual(t, err, nil)
+	data, _ := ioutil.ReadAll(r)
+	testing2.ExpectEqual(t, string(data), text)
+
+	rw = get("/text", "gzip;q=0, identity")
+	testing2.ExpectEqual(t, rw.Header().Get("Content-Encoding"), "")
+	testing2.ExpectEqual(t, rw.Body.String(), text)
+
+	rw = get("/small", "gzip")
+	testing2.ExpectEqual(t, rw.Header().Get("Content-Encoding"), "")
+	testing2.ExpectEqual(t, rw.Body.String(), "hi")
+
+	rw = get("/image", "gzip")
+	testing2.ExpectEqual(t, rw.Header().Get("Content-Encoding"), "")
+	testing2.ExpectEqual(t, rw.Body.String(), text)
+
+	rw = get("/stream", "gzip")
+	testing2.ExpectEqual(t, rw.Header().Get("Content-Encoding"), "gzip")
+	testing2.ExpectEqual(t, rw.Flushed, true)
+	testing2.ExpectEqual(t, decompress(rw), "data: 1\n\ndata: 2\n\n")
+
+	// request body
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(`{"name":"bob"}`))
+	w.Close()
+	req := httptest.NewRequest("POST", "/echo", &buf)
+	req.Header.Set("Content-Encoding", "gzip")
+	rw = serve(handler, req, m)
+	testing2.ExpectEqual(t, rw.Body.String(), `{"name":"bob"}`)
+
+	req = httptest.NewRequest("POST", "/echo", strings.NewReader("plain"))
+	req.Header.Set("Content-Encoding", "gzip")
+	rw = serve(handler, req, m)
+	testing2.ExpectEqual(t, rw.Code, http.StatusBadRequest)
+
+	req = httptest.NewRequest("POST", "/echo", strings.NewReader("plain"))
+	req.Header.Set("Content-Encoding", "compress")
+	rw = serve(handler, req, m)
+	testing2.ExpectEqual(t, rw.Code, http.StatusUnsupportedMediaType)
+
+	// decompression limit
+	upload := func(size int, middlewares ...http2.Middleware) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		w.Write(make([]byte, size))
+		w.Close()
+		req := httptest.NewRequest("POST", "/upload", &buf)
+		req.Header.Set("Content-Encoding", "gzip")
+		return serve(handler, req, middlewares...)
+	}
+	limited := NewCompress(gzip.DefaultCompression, DefaultCompressMinSize, WithDecompressLimit(100))
+	rw = upload(100, limited)
+	testing2.ExpectEqual(t, rw.Code, http.StatusOK)
+	testing2.ExpectEqual(t, rw.Body.String(), "100")
+	rw = upload(101, limited)
+	testing2.ExpectEqual(t, rw.Code, http.StatusRequestEntityTooLarge)
+	rw = upload(1000, NewBodyLimit(100), m) // the compressed body is under the limit
+	testing2.ExpectEqual(t, rw.Code, http.StatusRequestEntityTooLarge)
+	rw = upload(DefaultBodyLimit+1, m)
+	testing2.ExpectEqual(t, rw.Code, http.StatusRequestEntityTooLarge)
+}
+
+func TestResponseCache(t *testing.T) {
+	c, err := cache.New(lru.NewContainer(100))
+	testing2.AssertEqual(t, err, nil)
+	rc, err := NewResponseCache(c, time.Minute, log.NewLogger(&bytes.Buffer{}, ""))
+	testing2.AssertEqual(t, err, nil)
+
+	calls := 0
+	handler := func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		switch req.URL.Path {
+		case "/users":
+			rw.Header().Set(CacheTagHeader, "users")
+			rw.Header().Set("Vary", "Accept-Language")
+			fmt.Fprintf(rw, "users %s %d", req.Header.Get("Accept-Language"), calls)
+		case "/private":
+			rw.Header().Set("Cache-Control", "private")
+			fmt.Fprintf(rw, "private %d", calls)
+		}
+	}
+	get := func(path string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		return serve(handler, req, rc)
+	}
+
+	rw := get("/users?b=2&a=1")
+	testing2.ExpectEqual(t, rw.Header().Get("X-Cache"), "MISS")
+	testing2.ExpectEqual(t, rw.Header().Get(CacheTagHeader), "")
+	testing2.ExpectEqual(t, rw.Body.String(), "users  1")
+	etag := rw.Header().Get("ETag")
+	testing2.ExpectNotEqual(t, etag, "")
+	testing2.ExpectNotEqual(t, rw.Header().Get("Last-Modified"), "")
+
+	rw = get("/users?a=1&b=2")
+	testing2.ExpectEqual(t, rw.Header().Get("X-Cache"), "HIT")
+	testing2.ExpectEqual(t, rw.Body.String(), "users  1")
+	testing2.ExpectEqual(t, rw.Header().Get("ETag"), etag)
+
+	// vary
+	rw = get("/users?a=1&b=2", "Accept-Language", "zh")
+	testing2.ExpectEqual(t, rw.Header().Get("X-Cache"), "MISS")
+	testing2.ExpectEqual(t, rw.Body.String(), "users zh 2")
+
+	// conditional
+	rw = get("/users?a=1&b=2", "If-None-Match", etag)
+	testing2.ExpectEqual(t, rw.Code, http.StatusNotModified)
+	testing2.ExpectEqual(t, rw.Body.Len(), 0)
+
+	// head
+	req := httptest.NewRequest("HEAD", "/users?a=1&b=2", nil)
+	rw = serve(handler, req, rc)
+	testing2.ExpectEqual(t, rw.Header().Get("X-Cache"), "HIT")
+	testing2.ExpectEqual(t, rw.Body.Len(), 0)
+
+	// refresh
+	rw = get("/users?a=1&b=2", "Cache-Control", "no-cache")
+	testing2.ExpectEqual(t, rw.Body.String(), "users  3")
+	rw = get("/users?a=1&b=2")
+	testing2.ExpectEqual(t, rw.Body.String(), "users  3")
+
+	// invalidation
+	testing2.AssertEqual(t, rc.InvalidateTag("users"), nil)
+	rw = get("/users?a=1&b=2")
+	testing2.ExpectEqual(t, rw.Body.String(), "users  4")
+	testing2.AssertEqual(t, rc.Invalidate("/users"), nil)
+	rw = get("/users?a=1&b=2", "Accept-Language", "zh")
+	testing2.ExpectEqual(t, rw.Body.String(), "users zh 5")
+
+	// not cacheable
+	get("/private")
+	rw = get("/private")
+	testing2.ExpectEqual(t, rw.Header().Get("X-Cache"), "MISS")
+	testing2.ExpectEqual(t, rw.Body.String(), "private 7")
+
+	// another virtual host
+	rw = get("/users?a=1&b=2")
+	testing2.ExpectEqual(t, rw.Body.String(), "users  8")
+	req = httptest.NewRequest("GET", "/users?a=1&b=2", nil)
+	req.Host = "other.example.com"
+	rw = serve(handler, req, rc)
+	testing2.ExpectEqual(t, rw.Header().Get("X-Cache"), "MISS")
+	testing2.ExpectEqual(t, rw.Body.String(), "users  9")
+	rw = serve(handler, req, rc)
+	testing2.ExpectEqual(t, rw.Header().Get("X-Cache"), "HIT")
+	testing2.ExpectEqual(t, rw.Body.String(), "users  9")
+
+	// personalized
+	rw = get("/users?a=1&b=2", "Cookie", "session=1")
+	testing2.ExpectEqual(t, rw.Header().Get("X-Cache"), "")
+	testing2.ExpectEqual(t, rw.Body.String(), "users  10")
+
+	// the records of tags expire with the responses
+	item, err := c.Get(tagKey("users"))
+	testing2.AssertEqual(t, err, nil)
+	testing2.ExpectEqual(t, item.AbsoluteExpirationTime.IsZero(), false)
+	testing2.ExpectEqual(t, item.AbsoluteExpirationTime.After(time.Now().Add(59*time.Second)), true)
+	testing2.AssertEqual(t, rc.InvalidateTag("unknown"), nil)
+	item, _ = c.Get(tagKey("unknown"))
+	testing2.ExpectEqual(t, item, (*cache.Item)(nil))
+
+	// only the configured cookies bypass the cache
+	rc, err = NewResponseCache(c, time.Minute, log.NewLogger(&bytes.Buffer{}, ""), BypassCookies("session"))
+	testing2.AssertEqual(t, err, nil)
+	rw = get("/users?a=1&b=2", "Cookie", "_ga=1")
+	testing2.ExpectEqual(t, rw.Header().Get("X-Cache"), "HIT")
+	rw = get("/users?a=1&b=2", "Cookie", "_ga=1; session=1")
+	testing2.ExpectEqual(t, rw.Header().Get("X-Cache"), "")
+
+	// invalidation by route pattern, the cache is used by Router.Use or wraps the router
+	items := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(rw, "item %s", http2.ParamString(req, "id"))
+	})
+	inner := http2.NewRouter(nil).Use(rc)
+	inner.Get("/items/:id", items)
+	outer := http2.NewRouter(nil)
+	outer.Get("/products/:id", items)
+	for i, test := range []struct {
+		Handler http.Handler
+		Prefix  string
+	}{{inner, "/items/"}, {rc.Wrap(outer), "/products/"}} {
+		do := func(id string) string {
+			rw := httptest.NewRecorder()
+			test.Handler.ServeHTTP(rw, httptest.NewRequest("GET", test.Prefix+id, nil))
+			return rw.Header().Get("X-Cache")
+		}
+		do("1")
+		do("2")
+		testing2.ExpectEqualL(t, do("1"), "HIT", i)
+		testing2.ExpectEqualL(t, do("2"), "HIT", i)
+		testing2.AssertEqual(t, rc.Invalidate(test.Prefix+"1"), nil)
+		testing2.ExpectEqualL(t, do("1"), "MISS", i)
+		testing2.ExpectEqualL(t, do("2"), "HIT", i)
+		testing2.AssertEqual(t, rc.Invalidate(test.Prefix+":id"), nil)
+		testing2.ExpectEqualL(t, do("1"), "MISS", i)
+		testing2.ExpectEqualL(t, do("2"), "MISS", i)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	clock := testing2.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	handler := func(rw http.ResponseWriter, req *http.Request) {}
+	get := func(m http2.Middleware, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		return serve(handler, req, m)
+	}
+
+	// token bucket
+	m := NewRateLimit(NewTokenBucket(nil, 2, time.Second, WithClock(clock)), KeyByIP, nil)
+	rw := get(m, "10.0.0.1")
+	testing2.ExpectEqual(t, rw.Code, http.StatusOK)
+	testing2.ExpectEqual(t, rw.Header().Get("RateLimit-Limit"), "2")
+	testing2.ExpectEqual(t, rw.Header().Get("RateLimit-Remaining"), "1")
+	testing2.ExpectEqual(t, get(m, "10.0.0.1").Code, http.StatusOK)
+	rw = get(m, "10.0.0.1")
+	testing2.ExpectEqual(t, rw.Code, http.StatusTooManyRequests)
+	testing2.ExpectEqual(t, rw.Header().Get("RateLimit-Remaining"), "0")
+	testing2.ExpectEqual(t, rw.Header().Get("Retry-After"), "1")
+	testing2.ExpectEqual(t, get(m, "10.0.0.2").Code, http.StatusOK) // another client
+	clock.Advance(500 * time.Millisecond)
+	testing2.ExpectEqual(t, get(m, "10.0.0.1").Code, http.StatusOK)
+	testing2.ExpectEqual(t, get(m, "10.0.0.1").Code, http.StatusTooManyRequests)
+
+	// rotating the forwarding headers does not reset the bucket
+	for i, ip := range []string{"198.51.100.1", "198.51.100.2"} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Real-IP", ip)
+		req.Header.Set("X-Forwarded-For", ip)
+		testing2.ExpectEqualL(t, serve(handler, req, m).Code, http.StatusTooManyRequests, i)
+	}
+
+	// sliding window on container
+	store, err := NewContainerRateLimitStore(lru.NewContainer(100))
+	testing2.AssertEqual(t, err, nil)
+	m = NewRateLimit(NewSlidingWindow(store, 2, time.Minute, WithClock(clock)), KeyByHeader("X-API-Key"), nil)
+	key := func(k string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		if len(k) > 0 {
+			req.Header.Set("X-API-Key", k)
+		}
+		return serve(handler, req, m)
+	}
+	clock.Set(time.Date(2020, 1, 1, 0, 1, 0, 0, time.UTC))
+	testing2.ExpectEqual(t, key("a").Code, http.StatusOK)
+	testing2.ExpectEqual(t, key("a").Code, http.StatusOK)
+	rw = key("a")
+	testing2.ExpectEqual(t, rw.Code, http.StatusTooManyRequests)
+	testing2.ExpectEqual(t, rw.Header().Get("Retry-After"), "60")
+	testing2.ExpectEqual(t, key("").Code, http.StatusOK) // not limited
+
+	// the previous window is weighted by the overlap
+	clock.Advance(75 * time.Second)
+	rw = key("a")
+	testing2.ExpectEqual(t, rw.Code, http.StatusTooManyRequests) // 2*0.75 + 0 + 1 > 2
+	testing2.ExpectEqual(t, rw.Header().Get("Retry-After"), "15")
+	clock.Advance(15 * time.Second)
+	testing2.ExpectEqual(t, key("a").Code, http.StatusOK)
+
+	// the limit and period must be positive
+	for i, c := range []struct {
+		Limit  int
+		Period time.Duration
+	}{{0, time.Second}, {-1, time.Second}, {1, 0}, {1, -time.Second}} {
+		func() {
+			defer func() {
+				testing2.ExpectNotEqualL(t, recover(), nil, i)
+			}()
+			NewTokenBucket(nil, c.Limit, c.Period)
+		}()
+		func() {
+			defer func() {
+				testing2.ExpectNotEqualL(t, recover(), nil, i)
+			}()
+			NewSlidingWindow(nil, c.Limit, c.Period)
+		}()
+	}
+}
+
+func TestCORS(t *testing.T) {
+	router := http2.NewRouter(nil)
+	router.Get("/users", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("X-Total", "1")
+	}))
+	router.Post("/users", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+
+	_, err := NewCORS(AllowOriginRegexp("("))
+	testing2.ExpectNotEqual(t, err, nil)
+	_, err = NewCORS(AllowCredentials(true))
+	testing2.ExpectNotEqual(t, err, nil)
+	_, err = NewCORS(AllowOrigins("https://example.com", "*"), AllowCredentials(true))
+	testing2.ExpectNotEqual(t, err, nil)
+
+	m, err := NewCORS(
+		AllowOrigins("https://example.com", "https://*.example.org"),
+		AllowOriginRegexp(`^https://[a-z]+\.example\.net$`),
+		AllowCredentials(true),
+		ExposeHeaders("X-Total"),
+		MaxAge(10*time.Minute),
+	)
+	testing2.AssertEqual(t, err, nil)
+	h := m.Wrap(router)
+	do := func(method, path, origin string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if len(origin) > 0 {
+			req.Header.Set("Origin", origin)
+		}
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, req)
+		return rw
+	}
+
+	// actual requests
+	for i, origin := range []string{"https://example.com", "https://api.example.org", "https://api.example.net"} {
+		rw := do("GET", "/users", origin)
+		testing2.ExpectEqualL(t, rw.Header().Get("Access-Control-Allow-Origin"), origin, i)
+		testing2.ExpectEqualL(t, rw.Header().Get("Access-Control-Allow-Credentials"), "true", i)
+		testing2.ExpectEqualL(t, rw.Header().Get("Access-Control-Expose-Headers"), "X-Total", i)
+		testing2.ExpectEqualL(t, rw.Header().Get("Vary"), "Origin", i)
+	}
+	rw := do("GET", "/users", "https://evil.com")
+	testing2.ExpectEqual(t, rw.Header().Get("Access-Control-Allow-Origin"), "")
+	rw = do("GET", "/users", "")
+	testing2.ExpectEqual(t, rw.Header().Get("Access-Control-Allow-Origin"), "")
+
+	// preflight
+	rw = do("OPTIONS", "/users", "https://example.com", "Access-Control-Request-Method", "POST", "Access-Control-Request-Headers", "Content-Type")
+	testing2.ExpectEqual(t, rw.Code, http.StatusNoContent)
+	testing2.ExpectEqual(t, rw.Header().Get("Access-Control-Allow-Origin"), "https://example.com")
+	testing2.ExpectEqual(t, rw.Header().Get("Access-Control-Allow-Methods"), "GET, HEAD, OPTIONS, POST")
+	testing2.ExpectEqual(t, rw.Header().Get("Access-Control-Allow-Headers"), "Content-Type")
+	testing2.ExpectEqual(t, rw.Header().Get("Access-Control-Max-Age"), "600")
+
+	rw = do("OPTIONS", "/users", "https://example.com", "Access-Control-Request-Method", "DELETE")
+	testing2.ExpectEqual(t, rw.Header().Get("Access-Control-Allow-Origin"), "")
+
+	rw = do("OPTIONS", "/unknown", "https://example.com", "Access-Control-Request-Method", "GET")
+	testing2.ExpectEqual(t, rw.Code, http.StatusNotFound)
+	testing2.ExpectEqual(t, rw.Header().Get("Access-Control-Allow-Origin"), "")
+
+	// the response of handler registered for OPTIONS is kept
+	router.Options("/docs", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("docs"))
+	}))
+	rw = do("OPTIONS", "/docs", "https://example.com", "Access-Control-Request-Method", "OPTIONS")
+	testing2.ExpectEqual(t, rw.Code, http.StatusOK)
+	testing2.ExpectEqual(t, rw.Body.String(), "docs")
+	testing2.ExpectEqual(t, rw.Header().Get("Access-Control-Allow-Origin"), "https://example.com")
+
+	// used by Router.Use
+	router = http2.NewRouter(nil).Use(m)
+	router.Post("/users", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	h = router
+	rw = do("OPTIONS", "/users", "https://example.com", "Access-Control-Request-Method", "POST")
+	testing2.ExpectEqual(t, rw.Code, http.StatusNoContent)
+	testing2.ExpectEqual(t, rw.Header().Get("Access-Control-Allow-Origin"), "https://example.com")
+	testing2.ExpectEqual(t, rw.Header().Get("Access-Control-Allow-Methods"), "OPTIONS, POST")
+	rw = do("POST", "/users", "https://example.com")
+	testing2.ExpectEqual(t, rw.Header().Get("Access-Control-Expose-Headers"), "X-Total")
+
+	// all origins
+	m, _ = NewCORS()
+	rw = serve(func(rw http.ResponseWriter, req *http.Request) {}, func() *http.Request {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Origin", "https://any.com")
+		return req
+	}(), m)
+	testing2.ExpectEqual(t, rw.Header().Get("Access-Control-Allow-Origin"), "*")
+
+	// the origin is not echoed if "*" is mixed with explicit origins
+	m, _ = NewCORS(AllowOrigins("https://example.com", "*"))
+	rw = serve(func(rw http.ResponseWriter, req *http.Request) {}, func() *http.Request {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Origin", "https://any.com")
+		return req
+	}(), m)
+	testing2.ExpectEqual(t, rw.Header().Get("Access-Control-Allow-Origin"), "*")
+	testing2.ExpectEqual(t, rw.Header().Get("Access-Control-Allow-Credentials"), "")
+}
+
+func TestBasicAuth(t *testing.T) {
+	var principal *Principal
+	handler := func(rw http.ResponseWriter, req *http.Request) {
+		principal = PrincipalOf(req)
+	}
+	m := NewBasicAuth("api", BasicCredentials(map[string]string{"bob": "secret"}))
+
+	rw := serve(handler, httptest.NewRequest("GET", "/", nil), m)
+	testing2.ExpectEqual(t, rw.Code, http.StatusUnauthorized)
+	testing2.ExpectEqual(t, rw.Header().Get("WWW-Authenticate"), `Basic realm="api", charset="UTF-8"`)
+
+	for _, credentials := range [][2]string{{"bob", "wrong"}, {"alice", "secret"}, {"alice", ""}} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.SetBasicAuth(credentials[0], credentials[1])
+		testing2.ExpectEqual(t, serve(handler, req, m).Code, http.StatusUnauthorized)
+	}
+
+	principal = nil
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("bob", "secret")
+	rw = serve(handler, req, m)
+	testing2.ExpectEqual(t, rw.Code, http.StatusOK)
+	testing2.AssertNotEqual(t, principal, nil)
+	testing2.ExpectEqual(t, principal.Name, "bob")
+	testing2.ExpectEqual(t, principal.Scheme, "Basic")
+}
+
+func TestJWTAuth(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	testing2.AssertEqual(t, err, nil)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	testing2.AssertEqual(t, err, nil)
+	secret := []byte("secret")
+
+	keys := NewKeySet()
+	testing2.AssertEqual(t, keys.Add("hs", secret), nil)
+	testing2.AssertEqual(t, keys.Add("rs", &rsaKey.PublicKey), nil)
+	testing2.AssertEqual(t, keys.Add("es", &ecKey.PublicKey), nil)
+	testing2.ExpectNotEqual(t, keys.Add("bad", "key"), nil)
+	verifier := NewJWTVerifier(keys, WithAudience("api"), WithIssuer("auth"), WithLeeway(time.Second))
+
+	now := time.Now()
+	claims := func(exp time.Time) map[string]interface{} {
+		return map[string]interface{}{"sub": "bob", "iss": "auth", "aud": []string{"web", "api"}, "exp": exp.Unix()}
+	}
+	tests := []struct {
+		KeyID       string
+		Key         interface{}
+		Claims      map[string]interface{}
+		Description string // empty for valid token
+	}{
+		{"hs", secret, claims(now.Add(time.Minute)), ""},
+		{"rs", rsaKey, claims(now.Add(time.Minute)), ""},
+		{"es", ecKey, claims(now.Add(time.Minute)), ""},
+		{"hs", []byte("wrong"), claims(now.Add(time.Minute)), "signature invalid"},
+		{"rs", secret, claims(now.Add(time.Minute)), "signature invalid"}, // algorithm does not match the key
+		{"unknown", secret, claims(now.Add(time.Minute)), "signature invalid"},
+		{"hs", secret, claims(now.Add(-time.Minute)), "token expired"},
+		{"hs", secret, map[string]interface{}{"sub": "bob", "iss": "auth", "aud": "api", "nbf": now.Add(time.Minute).Unix()}, "token not valid yet"},
+		{"hs", secret, map[string]interface{}{"sub": "bob", "iss": "auth", "aud": "web"}, "claims invalid"},
+		{"hs", secret, map[string]interface{}{"sub": "bob", "iss": "other", "aud": "api"}, "claims invalid"},
+		{"hs", secret, map[string]interface{}{"sub": "bob", "iss": "auth", "aud": "api", "exp": "tomorrow"}, "malformed token"},
+	}
+	var principal *Principal
+	handler := func(rw http.ResponseWriter, req *http.Request) {
+		principal = PrincipalOf(req)
+	}
+	var logs bytes.Buffer
+	logger := log.NewLogger(&logs, "")
+	logger.SetLevel(log.LevelDebug)
+	m := NewJWTAuth(NewJWTVerifier(keys, WithAudience("api"), WithIssuer("auth"), WithLeeway(time.Second), WithJWTLogger(logger)))
+	for i, test := range tests {
+		token, err := SignJWT(test.Claims, test.KeyID, test.Key)
+		testing2.AssertEqualL(t, err, nil, i)
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		principal = nil
+		rw := serve(handler, req, m)
+		if len(test.Description) == 0 {
+			testing2.ExpectEqualL(t, rw.Code, http.StatusOK, i)
+			testing2.AssertNotEqualL(t, principal, nil, i)
+			testing2.ExpectEqualL(t, principal.Name, "bob", i)
+			testing2.ExpectEqualL(t, principal.Scheme, "Bearer", i)
+		} else {
+			testing2.ExpectEqualL(t, rw.Code, http.StatusUnauthorized, i)
+			testing2.ExpectEqualL(t, rw.Header().Get("WWW-Authenticate"), `Bearer error="invalid_token", error_description="`+test.Description+`"`, i)
+		}
+	}
+	testing2.ExpectEqual(t, strings.Contains(logs.String(), "jwt.go:"), true) // the details are logged only
+
+	// rotation
+	token, _ := SignJWT(claims(now.Add(time.Minute)), "hs", secret)
+	keys.Remove("hs")
+	_, err = verifier.Verify(token)
+	testing2.ExpectNotEqual(t, err, nil)
+
+	rw := serve(handler, httptest.NewRequest("GET", "/", nil), m)
+	testing2.ExpectEqual(t, rw.Code, http.StatusUnauthorized)
+	testing2.ExpectEqual(t, rw.Header().Get("WWW-Authenticate"), "Bearer")
+}
+
+func TestHMACAuth(t *testing.T) {
+	var principal *Principal
+	var body string
+	handler := func(rw http.ResponseWriter, req *http.Request) {
+		principal = PrincipalOf(req)
+		data, _ := ioutil.ReadAll(req.Body)
+		body = string(data)
+	}
+	m := NewHMACAuth(func(keyID string) ([]byte, error) {
+		if keyID == "client" {
+			return []byte("secret"), nil
+		}
+		return nil, nil
+	}, time.Minute, nil)
+
+	req := httptest.NewRequest("POST", "/orders?id=1", strings.NewReader(`{"qty":1}`))
+	testing2.AssertEqual(t, SignRequest(req, "client", []byte("secret")), nil)
+	headers := req.Header.Clone()
+	rw := serve(handler, req, m)
+	testing2.ExpectEqual(t, rw.Code, http.StatusOK)
+	testing2.AssertNotEqual(t, principal, nil)
+	testing2.ExpectEqual(t, principal.Name, "client")
+	testing2.ExpectEqual(t, body, `{"qty":1}`)
+
+	// replay
+	req = httptest.NewRequest("POST", "/orders?id=1", strings.NewReader(`{"qty":1}`))
+	req.Header = headers
+	testing2.ExpectEqual(t, serve(handler, req, m).Code, http.StatusUnauthorized)
+
+	// tampered body
+	req = httptest.NewRequest("POST", "/orders?id=1", strings.NewReader(`{"qty":1}`))
+	SignRequest(req, "client", []byte("secret"))
+	req.Body = ioutil.NopCloser(strings.NewReader(`{"qty":100}`))
+	testing2.ExpectEqual(t, serve(handler, req, m).Code, http.StatusUnauthorized)
+
+	// wrong key
+	req = httptest.NewRequest("GET", "/", nil)
+	SignRequest(req, "client", []byte("wrong"))
+	testing2.ExpectEqual(t, serve(handler, req, m).Code, http.StatusUnauthorized)
+
+	// expired
+	req = httptest.NewRequest("GET", "/", nil)
+	SignRequest(req, "client", []byte("secret"))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+	testing2.ExpectEqual(t, serve(handler, req, m).Code, http.StatusUnauthorized)
+
+	// another host
+	req = httptest.NewRequest("GET", "/", nil)
+	SignRequest(req, "client", []byte("secret"))
+	req.Host = "other.example.com"
+	testing2.ExpectEqual(t, serve(handler, req, m).Code, http.StatusUnauthorized)
+
+	// tampered content type
+	req = httptest.NewRequest("POST", "/orders", strings.NewReader(`{"qty":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	SignRequest(req, "client", []byte("secret"))
+	req.Header.Set("Content-Type", "application/xml")
+	testing2.ExpectEqual(t, serve(handler, req, m).Code, http.StatusUnauthorized)
+
+	// outgoing request
+	req = httptest.NewRequest("GET", "http://example.com/orders", nil)
+	req.Host = ""
+	SignRequest(req, "client", []byte("secret"))
+	req.Host = "example.com"
+	testing2.ExpectEqual(t, serve(handler, req, m).Code, http.StatusOK)
+
+	// body limit
+	req = httptest.NewRequest("POST", "/orders", strings.NewReader(`{"qty":1}`))
+	SignRequest(req, "client", []byte("secret"))
+	req.ContentLength = -1 // unknown
+	testing2.ExpectEqual(t, serve(handler, req, NewBodyLimit(5), m).Code, http.StatusRequestEntityTooLarge)
+}
@@ -0,0 +1,397 @@
+package middleware
+
+import (
+	"encoding/gob"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/wayn3h0/gop/cache"
+	"github.com/wayn3h0/gop/cache/container"
+	"github.com/wayn3h0/gop/clock"
+	"github.com/wayn3h0/gop/errors"
+	http2 "github.com/wayn3h0/gop/http"
+	"github.com/wayn3h0/gop/log"
+)
+
+// KeyFunc represents a func extracts the client key of rate limiting from request, the request is not limited if the key is empty.
+type KeyFunc func(req *http.Request) string
+
+// KeyByIP returns the client IP address resolved by RealIP func as the key.
+// The forwarding headers are honored only through the real IP middleware with trusted proxies, so clients cannot forge the key.
+func KeyByIP(req *http.Request) string {
+	return RealIP(req)
+}
+
+// KeyByHeader returns a KeyFunc uses the value of header (e.g. "X-API-Key") as the key.
+func KeyByHeader(name string) KeyFunc {
+	return func(req *http.Request) string {
+		return req.Header.Get(name)
+	}
+}
+
+// RateLimitStore represents a store of limiter states.
+type RateLimitStore interface {
+	// Get returns the state by given key, it returns nil if not found or expired.
+	Get(key string) (interface{}, error)
+
+	// Save inserts/updates the state with time to live.
+	Save(key string, state interface{}, ttl time.Duration) error
+}
+
+// memoryEntry represents an entry of memory store.
+type memoryEntry struct {
+	State      interface{}
+	Expiration time.Time
+}
+
+// memoryStore represents an in-memory store.
+type memoryStore struct {
+	mutex   sync.Mutex
+	entries map[string]*memoryEntry
+	saves   int
+}
+
+// Get implements RateLimitStore interface.
+func (s *memoryStore) Get(key string) (interface{}, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || !time.Now().Before(entry.Expiration) {
+		return nil, nil
+	}
+
+	return entry.State, nil
+}
+
+// Save implements RateLimitStore interface.
+func (s *memoryStore) Save(key string, state interface{}, ttl time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	s.entries[key] = &memoryEntry{
+		State:      state,
+		Expiration: now.Add(ttl),
+	}
+
+	// sweeps expired entries periodically
+	s.saves++
+	if s.saves%1024 == 0 {
+		for k, entry := range s.entries {
+			if !now.Before(entry.Expiration) {
+				delete(s.entries, k)
+			}
+		}
+	}
+
+	return nil
+}
+
+// NewMemoryRateLimitStore returns a new in-memory store.
+func NewMemoryRateLimitStore() RateLimitStore {
+	return &memoryStore{
+		entries: make(map[string]*memoryEntry),
+	}
+}
+
+// containerStore represents a store built on cache container.
+type containerStore struct {
+	container container.Container
+}
+
+// Get implements RateLimitStore interface.
+func (s *containerStore) Get(key string) (interface{}, error) {
+	v, err := s.container.Get("ratelimit:" + key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "http: could not get rate limit state with key %q", key)
+	}
+	item, ok := v.(*cache.Item)
+	if !ok || item.HasExpired() {
+		return nil, nil
+	}
+
+	return item.Value, nil
+}
+
+// Save implements RateLimitStore interface.
+func (s *containerStore) Save(key string, state interface{}, ttl time.Duration) error {
+	item, err := cache.NewItem("ratelimit:"+key, state)
+	if err != nil {
+		return err
+	}
+	item.SetAbsoluteExpiration(item.CreatedAt.Add(ttl))
+
+	err = s.container.Save(item.Key, item)
+	if err != nil {
+		return errors.Wrapf(err, "http: could not save rate limit state with key %q", key)
+	}
+
+	return nil
+}
+
+// NewContainerRateLimitStore returns a new store built on the cache container, it can be shared across nodes by a distributed container (e.g. memcached).
+// The states are read and written without distributed locking, the concurrent requests on different nodes may be counted once.
+func NewContainerRateLimitStore(c container.Container) (RateLimitStore, error) {
+	if c == nil {
+		return nil, errors.New("http: container of rate limit store cannot be nil")
+	}
+
+	return &containerStore{
+		container: c,
+	}, nil
+}
+
+// RateLimit represents the result of taking a request from limiter.
+type RateLimit struct {
+	Allowed    bool          // whether the request is allowed
+	Limit      int           // maximum number of requests in the period
+	Remaining  int           // remaining number of requests
+	Reset      time.Duration // time until the quota is fully restored (token bucket) or the current window ends (sliding window)
+	RetryAfter time.Duration // time until next request is allowed, if not allowed
+}
+
+// RateLimiter represents a rate limiter.
+type RateLimiter interface {
+	// Take takes a request of the key.
+	Take(key string) (*RateLimit, error)
+}
+
+// LimiterOption represents an option of rate limiter.
+type LimiterOption func(*limiter)
+
+// WithClock sets the clock of rate limiter.
+func WithClock(c clock.Clock) LimiterOption {
+	return func(l *limiter) {
+		l.clock = c
+	}
+}
+
+// limiter represents the common parts of rate limiters.
+type limiter struct {
+	mutex  sync.Mutex
+	store  RateLimitStore
+	clock  clock.Clock
+	limit  int
+	period time.Duration
+}
+
+// init initializes the limiter, it panics if the limit or period is not positive.
+func (l *limiter) init(store RateLimitStore, limit int, period time.Duration, options []LimiterOption) {
+	if limit <= 0 {
+		panic("http: limit of rate limiter must be positive")
+	}
+	if period <= 0 {
+		panic("http: period of rate limiter must be positive")
+	}
+	if store == nil {
+		store = NewMemoryRateLimitStore()
+	}
+	l.store = store
+	l.clock = clock.Default
+	l.limit = limit
+	l.period = period
+	for _, option := range options {
+		option(l)
+	}
+}
+
+// tokenBucketState represents the state of token bucket.
+type tokenBucketState struct {
+	Tokens  float64
+	Updated time.Time
+}
+
+// slidingWindowState represents the state of sliding window.
+type slidingWindowState struct {
+	Start    time.Time
+	Current  int
+	Previous int
+}
+
+// registers types for gob encoding (e.g. memcached container).
+func init() {
+	gob.Register(&tokenBucketState{})
+	gob.Register(&slidingWindowState{})
+}
+
+// tokenBucket represents a token bucket limiter.
+type tokenBucket struct {
+	limiter
+}
+
+// Take implements RateLimiter interface.
+func (b *tokenBucket) Take(key string) (*RateLimit, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := b.clock.Now()
+	v, err := b.store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	state, ok := v.(*tokenBucketState)
+	if !ok {
+		state = &tokenBucketState{Tokens: float64(b.limit), Updated: now}
+	}
+
+	rate := float64(b.limit) / float64(b.period) // tokens per nanosecond
+	if elapsed := now.Sub(state.Updated); elapsed > 0 {
+		state.Tokens = math.Min(float64(b.limit), state.Tokens+float64(elapsed)*rate)
+	}
+	state.Updated = now
+
+	result := &RateLimit{
+		Limit: b.limit,
+	}
+	if state.Tokens >= 1 {
+		state.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - state.Tokens) / rate))
+	}
+	result.Remaining = int(state.Tokens)
+	result.Reset = time.Duration(math.Ceil((float64(b.limit) - state.Tokens) / rate))
+
+	err = b.store.Save(key, state, b.period)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// NewTokenBucket returns a token bucket limiter allows bursts up to the limit, the tokens are refilled at limit per period.
+// The states are kept in the store, a new in-memory store is used if it's nil.
+// It panics if the limit or period is not positive.
+func NewTokenBucket(store RateLimitStore, limit int, period time.Duration, options ...LimiterOption) RateLimiter {
+	b := &tokenBucket{}
+	b.init(store, limit, period, options)
+
+	return b
+}
+
+// slidingWindow represents a sliding window limiter.
+type slidingWindow struct {
+	limiter
+}
+
+// Take implements RateLimiter interface.
+func (w *slidingWindow) Take(key string) (*RateLimit, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	now := w.clock.Now()
+	start := now.Truncate(w.period)
+	v, err := w.store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	state, ok := v.(*slidingWindowState)
+	if !ok {
+		state = &slidingWindowState{Start: start}
+	}
+	if !state.Start.Equal(start) {
+		if state.Start.Equal(start.Add(-w.period)) {
+			state.Previous = state.Current
+		} else {
+			state.Previous = 0
+		}
+		state.Current = 0
+		state.Start = start
+	}
+
+	elapsed := now.Sub(start)
+	weight := 1 - float64(elapsed)/float64(w.period) // weight of previous window
+	count := float64(state.Previous)*weight + float64(state.Current)
+
+	result := &RateLimit{
+		Limit: w.limit,
+		Reset: w.period - elapsed,
+	}
+	if count+1 <= float64(w.limit) {
+		state.Current++
+		count++
+		result.Allowed = true
+	} else if state.Current < w.limit && state.Previous > 0 {
+		// the weighted previous count decreases to allow one more request
+		needed := 1 - (float64(w.limit-state.Current)-1)/float64(state.Previous)
+		result.RetryAfter = time.Duration(needed*float64(w.period)) - elapsed
+	} else {
+		result.RetryAfter = w.period - elapsed
+	}
+	if result.RetryAfter < 0 {
+		result.RetryAfter = 0
+	}
+	result.Remaining = w.limit - int(math.Ceil(count))
+	if result.Remaining < 0 {
+		result.Remaining = 0
+	}
+
+	err = w.store.Save(key, state, 2*w.period)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// NewSlidingWindow returns a sliding window limiter allows the limit of requests in any window of period.
+// The count of window is estimated by the weighted count of previous window and the count of current window.
+// The states are kept in the store, a new in-memory store is used if it's nil.
+// It panics if the limit or period is not positive.
+func NewSlidingWindow(store RateLimitStore, limit int, period time.Duration, options ...LimiterOption) RateLimiter {
+	w := &slidingWindow{}
+	w.init(store, limit, period, options)
+
+	return w
+}
+
+// seconds returns the duration in seconds rounded up.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// NewRateLimit returns a middleware limits the requests by the limiter for each client identified by the key func (e.g. KeyByIP).
+// It sets RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers, and responds with 429 (Too Many Requests) and Retry-After header if exceeded.
+// The request is allowed if the limiter fails (e.g. the store is unavailable), the error is logged.
+func NewRateLimit(limiter RateLimiter, key KeyFunc, logger *log.Logger) http2.Middleware {
+	if key == nil {
+		key = KeyByIP
+	}
+	if logger == nil {
+		logger = log.DefaultLogger
+	}
+
+	return http2.MiddlewareFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			k := key(req)
+			if len(k) == 0 {
+				next.ServeHTTP(rw, req)
+				return
+			}
+
+			result, err := limiter.Take(k)
+			if err != nil {
+				logger.Warnf("http: could not take rate limit of %q: %s", k, errors.TreeMessage(err))
+				next.ServeHTTP(rw, req)
+				return
+			}
+
+			header := rw.Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("RateLimit-Reset", seconds(result.Reset))
+			if !result.Allowed {
+				header.Set("Retry-After", seconds(result.RetryAfter))
+				http.Error(rw, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(rw, req)
+		})
+	})
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/wayn3h0/gop/errors"
+	http2 "github.com/wayn3h0/gop/http"
+)
+
+type realIPKey struct{}
+
+// host returns the host of address without port.
+func host(addr string) string {
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		return h
+	}
+
+	return addr
+}
+
+// RealIP returns the client IP address resolved by the real IP middleware.
+// It returns the host of remote address if the middleware is not used.
+func RealIP(req *http.Request) string {
+	if ip, ok := req.Context().Value(realIPKey{}).(string); ok {
+		return ip
+	}
+
+	return host(req.RemoteAddr)
+}
+
+// NewRealIP returns a middleware resolves the client IP address, it can be retrieved by RealIP func.
+// The X-Real-IP and X-Forwarded-For headers are trusted only if the request comes from the trusted proxies (in CIDR notation or single IP),
+// otherwise the remote address is used.
+func NewRealIP(trusted ...string) (http2.Middleware, error) {
+	var networks []*net.IPNet
+	for _, str := range trusted {
+		if !strings.Contains(str, "/") {
+			if ip := net.ParseIP(str); ip != nil && ip.To4() != nil {
+				str += "/32"
+			} else {
+				str += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(str)
+		if err != nil {
+			return nil, errors.Wrapf(err, "http: could not parse trusted proxy %q", str)
+		}
+		networks = append(networks, network)
+	}
+
+	isTrusted := func(ip net.IP) bool {
+		for _, network := range networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return http2.MiddlewareFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			ip := host(req.RemoteAddr)
+			if remote := net.ParseIP(ip); remote != nil && isTrusted(remote) {
+				ip = resolve(req, ip, isTrusted)
+			}
+
+			ctx := context.WithValue(req.Context(), realIPKey{}, ip)
+			next.ServeHTTP(rw, req.WithContext(ctx))
+		})
+	}), nil
+}
+
+// resolve resolves the client IP address of request from the trusted proxy with the remote ip.
+// The proxies append to X-Forwarded-For, so it is walked from right to left, the first untrusted address is the client,
+// the walk stops at the malformed address since the hops before it cannot be trusted.
+// The X-Real-IP is used only if there is no X-Forwarded-For.
+func resolve(req *http.Request, remote string, isTrusted func(net.IP) bool) string {
+	var hops []string
+	for _, value := range req.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	if len(hops) == 0 {
+		if ip := net.ParseIP(strings.TrimSpace(req.Header.Get("X-Real-IP"))); ip != nil {
+			return ip.String()
+		}
+		return remote
+	}
+
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+		client = ip.String()
+		if !isTrusted(ip) {
+			break
+		}
+	}
+
+	return client
+}
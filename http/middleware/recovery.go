@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/wayn3h0/gop/errors"
+	http2 "github.com/wayn3h0/gop/http"
+	"github.com/wayn3h0/gop/log"
+)
+
+// NewRecovery returns a middleware recovers the panic in handler and responds with 500 (Internal Server Error).
+// The panic is logged as error, and the response body contains the error tree and the stack in debug mode.
+// The http.ErrAbortHandler is not recovered for aborting the response.
+func NewRecovery(logger *log.Logger, debugMode bool) http2.Middleware {
+	if logger == nil {
+		logger = log.DefaultLogger
+	}
+
+	return http2.MiddlewareFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			w := wrap(rw)
+			defer func() {
+				r := recover()
+				if r == nil {
+					return
+				}
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+
+				var err error
+				if e, ok := r.(error); ok {
+					err = errors.Wrapf(e, "http: panic recovered in handling %s %s", req.Method, req.URL.Path)
+				} else {
+					err = errors.Newf("http: panic recovered in handling %s %s: %v", req.Method, req.URL.Path, r)
+				}
+				stack := debug.Stack()
+				logger.Errorf("%s\n%s", errors.TreeMessage(err), stack)
+
+				if w.Written() { // too late to respond
+					return
+				}
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				w.Header().Set("X-Content-Type-Options", "nosniff")
+				w.WriteHeader(http.StatusInternalServerError)
+				if debugMode {
+					fmt.Fprintf(w, "%s\n\n%s", errors.TreeMessage(err), stack)
+				} else {
+					fmt.Fprint(w, http.StatusText(http.StatusInternalServerError))
+				}
+			}()
+
+			next.ServeHTTP(w, req)
+		})
+	})
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	http2 "github.com/wayn3h0/gop/http"
+	"github.com/wayn3h0/gop/uuid"
+)
+
+// DefaultRequestIDHeader is the default header of request ID.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limits the length of request ID from client.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// validRequestID reports whether the request ID from client is valid, it must be visible ASCII characters and not too long.
+func validRequestID(id string) bool {
+	if len(id) == 0 || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// RequestID returns the request ID set by the request ID middleware, it returns empty string if not found.
+func RequestID(req *http.Request) string {
+	id, _ := req.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a middleware assigns an ID to each request.
+// It reuses the ID in request header if it's valid (visible ASCII characters up to 128 bytes), otherwise a random UUID is generated.
+// The ID is set in response header and can be retrieved by RequestID func, DefaultRequestIDHeader used if header is empty.
+func NewRequestID(header string) http2.Middleware {
+	if len(header) == 0 {
+		header = DefaultRequestIDHeader
+	}
+
+	return http2.MiddlewareFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			id := req.Header.Get(header)
+			if !validRequestID(id) {
+				u, err := uuid.NewRandom()
+				if err == nil {
+					id = u.String()
+				}
+			}
+
+			rw.Header().Set(header, id)
+			ctx := context.WithValue(req.Context(), requestIDKey{}, id)
+			next.ServeHTTP(rw, req.WithContext(ctx))
+		})
+	})
+}
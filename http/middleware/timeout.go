@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	http2 "github.com/wayn3h0/gop/http"
+)
+
+// timeoutWriter represents a response writer buffers the response until the handler finishes or flushes.
+// The response is written through after it's flushed, so the streaming handlers keep working.
+type timeoutWriter struct {
+	rw       http.ResponseWriter
+	header   http.Header
+	buffer   bytes.Buffer
+	mutex    sync.Mutex
+	status   int
+	flushed  bool
+	timedOut bool
+}
+
+// Header implements http.ResponseWriter interface.
+func (w *timeoutWriter) Header() http.Header {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.flushed {
+		return w.rw.Header()
+	}
+
+	return w.header
+}
+
+// WriteHeader implements http.ResponseWriter interface.
+func (w *timeoutWriter) WriteHeader(status int) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.timedOut || w.status != 0 {
+		return
+	}
+	w.status = status
+}
+
+// Write implements http.ResponseWriter interface.
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.flushed {
+		return w.rw.Write(data)
+	}
+
+	return w.buffer.Write(data)
+}
+
+// Flush implements http.Flusher interface, it writes the buffered response and the following writes go through.
+func (w *timeoutWriter) Flush() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.timedOut {
+		return
+	}
+	if !w.flushed {
+		w.commit()
+		w.flushed = true
+	}
+	if flusher, ok := w.rw.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// commit writes the buffered header and body, the mutex should be held.
+func (w *timeoutWriter) commit() {
+	header := w.rw.Header()
+	for key, values := range w.header {
+		header[key] = values
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.rw.WriteHeader(w.status)
+	w.rw.Write(w.buffer.Bytes())
+	w.buffer.Reset()
+}
+
+// NewTimeout returns a middleware limits the time of handler, it responds with 503 (Service Unavailable) and the message if timed out.
+// The context of request is cancelled when timed out, the handler should check it for stopping the long running work.
+// The response is buffered until the handler finishes, unless it's flushed (http.Flusher):
+// the streaming response is written through and it's cut off if timed out.
+func NewTimeout(timeout time.Duration, message string) http2.Middleware {
+	return http2.MiddlewareFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			// the context is cancelled after the writer is marked as timed out, so the handler cannot write after it
+			ctx, cancel := context.WithCancel(req.Context())
+			defer cancel()
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+
+			w := &timeoutWriter{
+				rw:     rw,
+				header: make(http.Header),
+			}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						panicked <- r
+					}
+				}()
+				next.ServeHTTP(w, req.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case r := <-panicked:
+				panic(r)
+			case <-done:
+				w.mutex.Lock()
+				defer w.mutex.Unlock()
+				if !w.flushed {
+					w.commit()
+				}
+			case <-timer.C:
+				w.mutex.Lock()
+				defer w.mutex.Unlock()
+				w.timedOut = true
+				cancel()
+				if !w.flushed {
+					rw.WriteHeader(http.StatusServiceUnavailable)
+					io.WriteString(rw, message)
+				}
+			case <-req.Context().Done():
+				w.mutex.Lock()
+				defer w.mutex.Unlock()
+				w.timedOut = true
+			}
+		})
+	})
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/wayn3h0/gop/errors"
+)
+
+// responseWriter represents a wrapper for http.ResponseWriter records the status code and the size of body.
+type responseWriter struct {
+	http.ResponseWriter
+	Status int
+	Size   int64
+}
+
+// WriteHeader implements http.ResponseWriter interface.
+func (w *responseWriter) WriteHeader(status int) {
+	if w.Status == 0 {
+		w.Status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter interface.
+func (w *responseWriter) Write(data []byte) (int, error) {
+	if w.Status == 0 {
+		w.Status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(data)
+	w.Size += int64(n)
+
+	return n, err
+}
+
+// Written reports whether the header has been written.
+func (w *responseWriter) Written() bool {
+	return w.Status != 0
+}
+
+// ReadFrom implements io.ReaderFrom interface, so the underlying writer can send files efficiently (e.g. sendfile).
+func (w *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	if w.Status == 0 {
+		w.Status = http.StatusOK
+	}
+
+	var n int64
+	var err error
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		n, err = io.Copy(struct{ io.Writer }{w.ResponseWriter}, r)
+	}
+	w.Size += n
+
+	return n, err
+}
+
+// Unwrap returns the underlying response writer, it's used by http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Flush implements http.Flusher interface.
+func (w *responseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker interface.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("http: response writer does not support hijacking")
+	}
+
+	return hijacker.Hijack()
+}
+
+// wrap returns the response writer records the status code, it reuses the wrapper if rw is already wrapped.
+func wrap(rw http.ResponseWriter) *responseWriter {
+	if w, ok := rw.(*responseWriter); ok {
+		return w
+	}
+
+	return &responseWriter{
+		ResponseWriter: rw,
+	}
+}
@@ -0,0 +1,228 @@
+package http
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/wayn3h0/gop/errors"
+)
+
+// Encoder represents an encoder writes the object in a media type.
+type Encoder interface {
+	// Encode writes the obj to w, the indent is empty if pretty-printing is disabled.
+	Encode(w io.Writer, obj interface{}, indent string) error
+}
+
+// EncoderFunc an adapter to allow the use of ordinary functions as encoders.
+type EncoderFunc func(w io.Writer, obj interface{}, indent string) error
+
+// Encode implements Encoder interface.
+func (f EncoderFunc) Encode(w io.Writer, obj interface{}, indent string) error {
+	return f(w, obj, indent)
+}
+
+// encodeJSON streams the obj as JSON.
+func encodeJSON(w io.Writer, obj interface{}, indent string) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", indent)
+	return encoder.Encode(obj)
+}
+
+// encodeXML streams the obj as XML with header.
+func encodeXML(w io.Writer, obj interface{}, indent string) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", indent)
+	return encoder.Encode(obj)
+}
+
+// encodeText writes the obj as text by fmt.Fprint.
+func encodeText(w io.Writer, obj interface{}, indent string) error {
+	_, err := fmt.Fprint(w, obj)
+	return err
+}
+
+var (
+	encodersMutex sync.RWMutex
+	encoders      = map[string]Encoder{
+		"application/json": EncoderFunc(encodeJSON),
+		"application/xml":  EncoderFunc(encodeXML),
+		"text/xml":         EncoderFunc(encodeXML),
+		"text/plain":       EncoderFunc(encodeText),
+	}
+	mediaTypes = []string{"application/json", "application/xml", "text/plain", "text/xml"} // in preference order
+)
+
+// RegisterEncoder registers the encoder for the media type, it replaces the encoder if the media type has been registered.
+// The registered media types are preferred in order of registration if the client accepts them equally.
+func RegisterEncoder(mediaType string, encoder Encoder) {
+	encodersMutex.Lock()
+	defer encodersMutex.Unlock()
+
+	mediaType = strings.ToLower(mediaType)
+	if _, ok := encoders[mediaType]; !ok {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	encoders[mediaType] = encoder
+}
+
+// acceptance represents a media range in Accept header.
+type acceptance struct {
+	Type    string
+	Quality float64
+}
+
+// specificity returns the specificity of media range, the more specific one takes precedence.
+func (a acceptance) specificity() int {
+	switch {
+	case a.Type == "*/*":
+		return 0
+	case strings.HasSuffix(a.Type, "/*"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// matches reports whether the media type matches the media range.
+func (a acceptance) matches(mediaType string) bool {
+	if a.Type == "*/*" || a.Type == mediaType {
+		return true
+	}
+
+	return strings.HasSuffix(a.Type, "/*") && strings.HasPrefix(mediaType, a.Type[:len(a.Type)-1])
+}
+
+// parseAccept parses the Accept header, the media ranges are sorted by quality and specificity.
+func parseAccept(header string) []acceptance {
+	var list []acceptance
+	for _, item := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+		}
+		list = append(list, acceptance{Type: mediaType, Quality: quality})
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Quality != list[j].Quality {
+			return list[i].Quality > list[j].Quality
+		}
+		return list[i].specificity() > list[j].specificity()
+	})
+
+	return list
+}
+
+// negotiate returns the media type and encoder accepted by the Accept header, JSON is used if the header is empty.
+func negotiate(header string) (string, Encoder, bool) {
+	encodersMutex.RLock()
+	defer encodersMutex.RUnlock()
+
+	if len(strings.TrimSpace(header)) == 0 {
+		return "application/json", encoders["application/json"], true
+	}
+
+	accepts := parseAccept(header)
+	for _, accept := range accepts {
+		if accept.Quality <= 0 {
+			continue
+		}
+		for _, mediaType := range mediaTypes {
+			if !accept.matches(mediaType) {
+				continue
+			}
+			// the media type is rejected explicitly (q=0) by a more specific range
+			rejected := false
+			for _, other := range accepts {
+				if other.Quality <= 0 && other.matches(mediaType) && other.specificity() > accept.specificity() {
+					rejected = true
+					break
+				}
+			}
+			if !rejected {
+				return mediaType, encoders[mediaType], true
+			}
+		}
+	}
+
+	return "", nil, false
+}
+
+// callbackRegexp limits the JSONP callback for avoiding script injection.
+var callbackRegexp = regexp.MustCompile(`^[A-Za-z_$][0-9A-Za-z_$.]*$`)
+
+// Negotiate writes the obj with status code in the media type accepted by the request (set by WithRequest).
+// The JSON, XML, text and the media types registered by RegisterEncoder are supported, JSON is used if no Accept header.
+// It responds with 406 (Not Acceptable) if none is accepted.
+// The JSON is wrapped by the callback if JSONP is enabled and the callback is present in query.
+func (r ResponseWriter) Negotiate(status int, obj interface{}) error {
+	header := ""
+	if r.request != nil {
+		header = r.request.Header.Get("Accept")
+	}
+
+	mediaType, encoder, ok := negotiate(header)
+	if !ok {
+		http.Error(r.ResponseWriter, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+		return errors.Newf("http: none of media types is acceptable for %q", header)
+	}
+
+	callback := ""
+	if mediaType == "application/json" && len(r.jsonp) > 0 && r.request != nil {
+		callback = r.request.URL.Query().Get(r.jsonp)
+		if !callbackRegexp.MatchString(callback) {
+			callback = ""
+		}
+	}
+
+	contentType := mediaType
+	if len(callback) > 0 {
+		contentType = "application/javascript"
+	}
+	if strings.HasPrefix(contentType, "text/") || contentType == "application/json" || contentType == "application/xml" || contentType == "application/javascript" {
+		contentType += "; charset=utf-8"
+	}
+	r.ResponseWriter.Header().Set("Content-Type", contentType)
+	r.ResponseWriter.Header().Add("Vary", "Accept")
+	r.ResponseWriter.WriteHeader(status)
+
+	if len(callback) > 0 {
+		_, err := io.WriteString(r.ResponseWriter, "/**/"+callback+"(")
+		if err != nil {
+			return errors.Wrap(err, "http: could not write JSONP callback to response")
+		}
+	}
+	err := encoder.Encode(r.ResponseWriter, obj, r.indent)
+	if err != nil {
+		return errors.Wrapf(err, "http: could not write %s data to response", mediaType)
+	}
+	if len(callback) > 0 {
+		_, err = io.WriteString(r.ResponseWriter, ");")
+		if err != nil {
+			return errors.Wrap(err, "http: could not write JSONP callback to response")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	testing2 "github.com/wayn3h0/gop/testing"
+)
+
+type user struct {
+	Name string `json:"name" xml:"name"`
+}
+
+func (u user) String() string {
+	return "user " + u.Name
+}
+
+func TestNegotiate(t *testing.T) {
+	RegisterEncoder("text/csv", EncoderFunc(func(w io.Writer, obj interface{}, indent string) error {
+		_, err := fmt.Fprintf(w, "name\n%s\n", obj.(user).Name)
+		return err
+	}))
+
+	tests := []struct {
+		Accept      string
+		Status      int
+		ContentType string
+		Body        string
+	}{
+		{"", 201, "application/json; charset=utf-8", "{\"name\":\"bob\"}\n"},
+		{"*/*", 201, "application/json; charset=utf-8", "{\"name\":\"bob\"}\n"},
+		{"application/xml;q=0.9, application/json;q=0.8", 201, "application/xml; charset=utf-8", "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<user><name>bob</name></user>"},
+		{"text/*, text/plain;q=0", 201, "text/xml; charset=utf-8", "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<user><name>bob</name></user>"},
+		{"text/plain", 201, "text/plain; charset=utf-8", "user bob"},
+		{"text/csv, */*;q=0.1", 201, "text/csv; charset=utf-8", "name\nbob\n"},
+		{"image/png", 406, "text/plain; charset=utf-8", "Not Acceptable\n"},
+	}
+	for i, test := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", test.Accept)
+		rw := httptest.NewRecorder()
+		err := NewResponseWriter(rw, WithRequest(req)).Negotiate(http.StatusCreated, user{Name: "bob"})
+		testing2.ExpectEqualL(t, err == nil, test.Status != 406, i)
+		testing2.ExpectEqualL(t, rw.Code, test.Status, i)
+		testing2.ExpectEqualL(t, rw.Header().Get("Content-Type"), test.ContentType, i)
+		testing2.ExpectEqualL(t, rw.Body.String(), test.Body, i)
+	}
+}
+
+func TestNegotiateOptions(t *testing.T) {
+	req := httptest.NewRequest("GET", "/?cb=render", nil)
+	rw := httptest.NewRecorder()
+	NewResponseWriter(rw, WithRequest(req), WithJSONP("cb"), WithIndent("  ")).Negotiate(http.StatusOK, user{Name: "bob"})
+	testing2.ExpectEqual(t, rw.Header().Get("Content-Type"), "application/javascript; charset=utf-8")
+	testing2.ExpectEqual(t, rw.Body.String(), "/**/render({\n  \"name\": \"bob\"\n}\n);")
+
+	req = httptest.NewRequest("GET", "/?cb=alert(1)", nil)
+	rw = httptest.NewRecorder()
+	NewResponseWriter(rw, WithRequest(req), WithJSONP("cb")).Negotiate(http.StatusOK, user{Name: "bob"})
+	testing2.ExpectEqual(t, rw.Body.String(), "{\"name\":\"bob\"}\n")
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/wayn3h0/gop/errors"
+	"github.com/wayn3h0/gop/uuid"
+)
+
+type parametersKey struct{}
+
+// parametersOf returns the parameters in the context of request, it returns nil if not found.
+func parametersOf(req *http.Request) map[interface{}]interface{} {
+	params, _ := req.Context().Value(parametersKey{}).(map[interface{}]interface{})
+	return params
+}
+
+// withParameters returns a shallow copy of request with empty parameters in context.
+func withParameters(req *http.Request) *http.Request {
+	ctx := context.WithValue(req.Context(), parametersKey{}, make(map[interface{}]interface{}))
+	return req.WithContext(ctx)
+}
+
+type patternKey struct{}
+
+// WithPattern returns the request carries the route pattern it matched, it's used by the implementations of ServeMux.
+// The request is a shallow copy of req if req has no parameters.
+func WithPattern(req *http.Request, pattern string) *http.Request {
+	r := NewRequest(req)
+	r.SetParameter(patternKey{}, pattern)
+
+	return r.Request
+}
+
+// Pattern returns the route pattern matched by the request (e.g. "/users/:id"), it returns empty string if not found.
+// The middlewares wrapping the mux can read it after the request is served if the request has parameters (e.g. by NewRequest).
+func Pattern(req *http.Request) string {
+	v, _ := parametersOf(req)[patternKey{}].(string)
+	return v
+}
+
+// Param represents a route parameter.
+type Param struct {
+	Key   string
+	Value string
+}
+
+// WithParams returns the request carries the route parameters in context, it's used by the implementations of ServeMux.
+// The request is a shallow copy of req if req has no parameters.
+func WithParams(req *http.Request, params ...Param) *http.Request {
+	r := NewRequest(req)
+	for _, p := range params {
+		r.SetParameter(p.Key, p.Value)
+	}
+
+	return r.Request
+}
+
+// ParamString returns the route parameter by name, it returns empty string if not found.
+func ParamString(req *http.Request, name string) string {
+	v, _ := parametersOf(req)[name].(string)
+	return v
+}
+
+// ParamInt returns the route parameter by name as integer.
+func ParamInt(req *http.Request, name string) (int, error) {
+	str := ParamString(req, name)
+	v, err := strconv.Atoi(str)
+	if err != nil {
+		return 0, errors.Wrapf(err, "http: could not parse parameter %q as integer", name)
+	}
+
+	return v, nil
+}
+
+// ParamUUID returns the route parameter by name as UUID.
+func ParamUUID(req *http.Request, name string) (uuid.UUID, error) {
+	str := ParamString(req, name)
+	v, err := uuid.Parse(str)
+	if err != nil {
+		return uuid.Nil, errors.Wrapf(err, "http: could not parse parameter %q as UUID", name)
+	}
+
+	return v, nil
+}
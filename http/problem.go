@@ -0,0 +1,311 @@
+package http
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"github.com/wayn3h0/gop/errors"
+	"github.com/wayn3h0/gop/i18n"
+	"github.com/wayn3h0/gop/log"
+)
+
+// Error represents an HTTP error, it's rendered as problem details (RFC 7807) by ErrorHandler.
+// The message is public for the client, the cause is internal and only shown in debug mode.
+type Error struct {
+	Status   int         // HTTP status code
+	Code     string      // machine-readable code (e.g. "out_of_credit")
+	Message  string      // public message
+	Messages i18n.String // localized public messages, the one in the language of request takes precedence
+	Cause    error       // internal cause
+
+	location string
+}
+
+// NewError returns a new HTTP error.
+func NewError(status int, code string, message string) *Error {
+	return &Error{
+		Status:   status,
+		Code:     code,
+		Message:  message,
+		location: caller(2),
+	}
+}
+
+// WrapError returns a new HTTP error caused by the err.
+func WrapError(err error, status int, code string, message string) *Error {
+	return &Error{
+		Status:   status,
+		Code:     code,
+		Message:  message,
+		Cause:    err,
+		location: caller(2),
+	}
+}
+
+// caller returns the source location (filename:line) of the caller.
+func caller(skip int) string {
+	_, file, line, ok := runtime.Caller(skip)
+	if !ok {
+		return ""
+	}
+
+	return fmt.Sprintf("%s:%d", file, line)
+}
+
+// Localize sets the public message in the language.
+func (e *Error) Localize(lang *i18n.Language, message string) *Error {
+	if e.Messages == nil {
+		e.Messages = make(i18n.String)
+	}
+	e.Messages.Set(lang, message)
+
+	return e
+}
+
+// Error implements builtin.error interface.
+func (e *Error) Error() string {
+	message := e.Message
+	if len(message) == 0 {
+		message = http.StatusText(e.Status)
+	}
+	message = fmt.Sprintf("http: %d %s", e.Status, message)
+	if e.Cause != nil {
+		message += ": " + e.Cause.Error()
+	}
+
+	return message
+}
+
+// Unwrap returns the cause.
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Problem represents the problem details (RFC 7807).
+// The trace and location are only present in debug mode.
+type Problem struct {
+	XMLName  xml.Name    `json:"-" xml:"urn:ietf:rfc:7807 problem"`
+	Type     string      `json:"type,omitempty" xml:"type,omitempty"`
+	Title    string      `json:"title" xml:"title"`
+	Status   int         `json:"status" xml:"status"`
+	Detail   string      `json:"detail,omitempty" xml:"detail,omitempty"`
+	Instance string      `json:"instance,omitempty" xml:"instance,omitempty"`
+	Code     string      `json:"code,omitempty" xml:"code,omitempty"`
+	Errors   FieldErrors `json:"errors,omitempty" xml:"errors>error,omitempty"`
+	Trace    string      `json:"trace,omitempty" xml:"trace,omitempty"`
+	Location string      `json:"location,omitempty" xml:"location,omitempty"`
+}
+
+// ErrorHandler represents a central handler responds errors as problem details (RFC 7807).
+//
+// The *Error (may be wrapped) is responded with its status, code and localized message.
+// The FieldErrors (e.g. returned by Request.Bind) is responded with 422 (Unprocessable Entity) and the errors of fields.
+// Others are responded with 500 (Internal Server Error) and no detail.
+// The errors with status 5xx are logged.
+type ErrorHandler struct {
+	debug    bool
+	logger   *log.Logger
+	typeBase string
+}
+
+// ErrorHandlerOption represents an option of error handler.
+type ErrorHandlerOption func(*ErrorHandler)
+
+// WithDebug enables the debug mode, the error tree and the source location are responded.
+func WithDebug(enabled bool) ErrorHandlerOption {
+	return func(h *ErrorHandler) {
+		h.debug = enabled
+	}
+}
+
+// WithLogger sets the logger for logging the errors with status 5xx.
+func WithLogger(logger *log.Logger) ErrorHandlerOption {
+	return func(h *ErrorHandler) {
+		h.logger = logger
+	}
+}
+
+// WithTypeBase sets the base URI of problem type, the type is the base URI followed by the code (e.g. "https://example.com/problems/out_of_credit").
+func WithTypeBase(base string) ErrorHandlerOption {
+	return func(h *ErrorHandler) {
+		h.typeBase = base
+	}
+}
+
+// NewErrorHandler returns a new error handler.
+func NewErrorHandler(options ...ErrorHandlerOption) *ErrorHandler {
+	h := &ErrorHandler{
+		logger: log.DefaultLogger,
+	}
+	for _, option := range options {
+		option(h)
+	}
+
+	return h
+}
+
+// DefaultErrorHandler is the default error handler used by HandleError func.
+var DefaultErrorHandler = NewErrorHandler()
+
+// HandleError responds the err by DefaultErrorHandler.
+func HandleError(rw http.ResponseWriter, req *http.Request, err error) {
+	DefaultErrorHandler.Handle(rw, req, err)
+}
+
+// unwrapper represents an error wraps another one.
+type unwrapper interface {
+	Unwrap() error
+}
+
+// findError returns the first *Error or FieldErrors in the chain of err.
+func findError(err error) (*Error, FieldErrors) {
+	for err != nil {
+		switch e := err.(type) {
+		case *Error:
+			return e, nil
+		case FieldErrors:
+			return nil, e
+		}
+		u, ok := err.(unwrapper)
+		if !ok {
+			break
+		}
+		err = u.Unwrap()
+	}
+
+	return nil, nil
+}
+
+// languages returns the languages accepted by the Accept-Language header in preference order.
+func languages(header string) []*i18n.Language {
+	var langs []*i18n.Language
+	for _, accept := range parseAccept(header) { // language ranges share the syntax of media ranges
+		if accept.Quality <= 0 {
+			continue
+		}
+		if lang, ok := i18n.LookupLanguage(accept.Type); ok {
+			langs = append(langs, lang)
+		} else if i := strings.IndexByte(accept.Type, '-'); i > 0 {
+			if lang, ok := i18n.LookupLanguage(accept.Type[:i]); ok {
+				langs = append(langs, lang)
+			}
+		}
+	}
+
+	return langs
+}
+
+// Problem returns the problem details of err for the request, the second result is the language of detail if localized.
+func (h *ErrorHandler) Problem(req *http.Request, err error) (*Problem, *i18n.Language) {
+	problem := &Problem{
+		Status: http.StatusInternalServerError,
+	}
+	if req != nil && req.URL != nil {
+		problem.Instance = req.URL.Path
+	}
+
+	var language *i18n.Language
+	cause := err
+	e, fieldErrors := findError(err)
+	switch {
+	case e != nil:
+		problem.Status = e.Status
+		problem.Code = e.Code
+		problem.Detail = e.Message
+		if len(e.Messages) > 0 && req != nil {
+			for _, lang := range languages(req.Header.Get("Accept-Language")) {
+				if message, ok := e.Messages.Get(lang); ok {
+					problem.Detail = message
+					language = lang
+					break
+				}
+			}
+		}
+		if fe, ok := e.Cause.(FieldErrors); ok {
+			problem.Errors = fe
+		}
+		if e.Cause != nil {
+			cause = e.Cause
+		}
+		if h.debug {
+			problem.Location = e.location
+		}
+	case fieldErrors != nil:
+		problem.Status = http.StatusUnprocessableEntity
+		problem.Code = "invalid_fields"
+		problem.Detail = "one or more fields are invalid"
+		problem.Errors = fieldErrors
+	}
+
+	problem.Title = http.StatusText(problem.Status)
+	if len(h.typeBase) > 0 && len(problem.Code) > 0 {
+		problem.Type = h.typeBase + problem.Code
+	}
+	if h.debug && cause != nil {
+		problem.Trace = errors.TreeMessage(cause)
+	}
+
+	return problem, language
+}
+
+// problemTypes lists the media types of problem details in preference order.
+var problemTypes = []string{"application/problem+json", "application/problem+xml", "application/json", "application/xml", "text/xml"}
+
+// Handle responds the err as problem details, the media type is negotiated by Accept header, JSON is used if none is accepted.
+func (h *ErrorHandler) Handle(rw http.ResponseWriter, req *http.Request, err error) {
+	problem, language := h.Problem(req, err)
+	if problem.Status >= 500 && h.logger != nil {
+		if req != nil {
+			h.logger.Errorf("http: could not handle %s %s:\n%s", req.Method, req.URL.Path, errors.TreeMessage(err))
+		} else {
+			h.logger.Errorf("http: could not handle request:\n%s", errors.TreeMessage(err))
+		}
+	}
+
+	xmlPreferred := false
+	if req != nil {
+	loop:
+		for _, accept := range parseAccept(req.Header.Get("Accept")) {
+			if accept.Quality <= 0 {
+				continue
+			}
+			for _, mediaType := range problemTypes {
+				if accept.matches(mediaType) {
+					xmlPreferred = strings.HasSuffix(mediaType, "xml")
+					break loop
+				}
+			}
+		}
+	}
+
+	header := rw.Header()
+	header.Del("Content-Length")
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Add("Vary", "Accept")
+	if language != nil {
+		header.Set("Content-Language", language.Code)
+		header.Add("Vary", "Accept-Language")
+	}
+	if xmlPreferred {
+		header.Set("Content-Type", "application/problem+xml")
+		rw.WriteHeader(problem.Status)
+		encodeXML(rw, problem, "")
+	} else {
+		header.Set("Content-Type", "application/problem+json")
+		rw.WriteHeader(problem.Status)
+		encodeJSON(rw, problem, "")
+	}
+}
+
+// Wrap returns a handler calls the fn and responds the returned error.
+func (h *ErrorHandler) Wrap(fn func(http.ResponseWriter, *http.Request) error) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		err := fn(rw, req)
+		if err != nil {
+			h.Handle(rw, req, err)
+		}
+	})
+}
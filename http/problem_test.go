@@ -0,0 +1,101 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/wayn3h0/gop/errors"
+	"github.com/wayn3h0/gop/i18n"
+	"github.com/wayn3h0/gop/log"
+	testing2 "github.com/wayn3h0/gop/testing"
+)
+
+func TestErrorHandler(t *testing.T) {
+	var logs bytes.Buffer
+	zh, _ := i18n.LookupLanguage("zh")
+	handler := NewErrorHandler(WithLogger(log.NewLogger(&logs, "")), WithTypeBase("https://example.com/problems/"))
+	h := handler.Wrap(func(rw http.ResponseWriter, req *http.Request) error {
+		switch req.URL.Path {
+		case "/credit":
+			err := NewError(http.StatusForbidden, "out_of_credit", "Your balance is too low.").Localize(zh, "余额不足。")
+			return errors.Wrap(err, "could not pay")
+		case "/fields":
+			return FieldErrors{{Field: "page", Rule: "min", Message: "must be at least 1"}}
+		case "/internal":
+			return errors.New("secret failure")
+		}
+		return nil
+	})
+
+	// public error
+	req := httptest.NewRequest("GET", "/credit", nil)
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	testing2.ExpectEqual(t, rw.Code, http.StatusForbidden)
+	testing2.ExpectEqual(t, rw.Header().Get("Content-Type"), "application/problem+json")
+	var problem Problem
+	testing2.AssertEqual(t, json.Unmarshal(rw.Body.Bytes(), &problem), nil)
+	testing2.ExpectEqual(t, problem.Type, "https://example.com/problems/out_of_credit")
+	testing2.ExpectEqual(t, problem.Title, "Forbidden")
+	testing2.ExpectEqual(t, problem.Status, http.StatusForbidden)
+	testing2.ExpectEqual(t, problem.Detail, "Your balance is too low.")
+	testing2.ExpectEqual(t, problem.Instance, "/credit")
+	testing2.ExpectEqual(t, problem.Code, "out_of_credit")
+	testing2.ExpectEqual(t, problem.Trace, "")
+	testing2.ExpectEqual(t, problem.Location, "")
+	testing2.ExpectEqual(t, logs.Len(), 0)
+
+	// localized message
+	req = httptest.NewRequest("GET", "/credit", nil)
+	req.Header.Set("Accept-Language", "fr;q=0.9, zh-CN, en;q=0.5")
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	testing2.ExpectEqual(t, rw.Header().Get("Content-Language"), "zh")
+	testing2.AssertEqual(t, json.Unmarshal(rw.Body.Bytes(), &problem), nil)
+	testing2.ExpectEqual(t, problem.Detail, "余额不足。")
+
+	// xml
+	req = httptest.NewRequest("GET", "/credit", nil)
+	req.Header.Set("Accept", "application/xml")
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	testing2.ExpectEqual(t, rw.Header().Get("Content-Type"), "application/problem+xml")
+	testing2.ExpectEqual(t, strings.Contains(rw.Body.String(), `<problem xmlns="urn:ietf:rfc:7807">`), true)
+	testing2.ExpectEqual(t, strings.Contains(rw.Body.String(), `<code>out_of_credit</code>`), true)
+
+	// field errors
+	req = httptest.NewRequest("GET", "/fields", nil)
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	testing2.ExpectEqual(t, rw.Code, http.StatusUnprocessableEntity)
+	problem = Problem{}
+	testing2.AssertEqual(t, json.Unmarshal(rw.Body.Bytes(), &problem), nil)
+	testing2.ExpectEqual(t, problem.Code, "invalid_fields")
+	testing2.AssertEqual(t, len(problem.Errors), 1)
+	testing2.ExpectEqual(t, *problem.Errors[0], FieldError{Field: "page", Rule: "min", Message: "must be at least 1"})
+
+	// internal error is hidden and logged
+	req = httptest.NewRequest("GET", "/internal", nil)
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	testing2.ExpectEqual(t, rw.Code, http.StatusInternalServerError)
+	testing2.ExpectEqual(t, strings.Contains(rw.Body.String(), "secret"), false)
+	testing2.ExpectEqual(t, strings.Contains(logs.String(), "secret failure"), true)
+}
+
+func TestErrorHandlerDebug(t *testing.T) {
+	handler := NewErrorHandler(WithDebug(true), WithLogger(log.NewLogger(&bytes.Buffer{}, "")))
+	cause := errors.New("connection refused")
+	err := WrapError(cause, http.StatusServiceUnavailable, "unavailable", "Try again later.")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	problem, _ := handler.Problem(req, err)
+	testing2.ExpectEqual(t, problem.Status, http.StatusServiceUnavailable)
+	testing2.ExpectEqual(t, problem.Trace, errors.TreeMessage(cause))
+	testing2.ExpectEqual(t, strings.Contains(problem.Location, "problem_test.go:"), true)
+	testing2.ExpectEqual(t, strings.HasPrefix(err.Error(), "http: 503 Try again later.: "), true)
+}
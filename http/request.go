@@ -3,7 +3,6 @@ package http
 import (
 	"encoding/json"
 	"encoding/xml"
-	"io"
 	"io/ioutil"
 	"net/http"
 	"strings"
@@ -11,33 +10,38 @@ import (
 	"github.com/wayn3h0/gop/errors"
 )
 
-type requestBody struct {
-	io.ReadCloser
-	Parameters map[interface{}]interface{}
-}
-
 // Request represents a wrapper for http request.
-// It stores the parameters directly in the http.Request by mutating the request.Body.
+// It stores the parameters in the context of http.Request, the requests derived from it share the parameters.
 type Request struct {
 	*http.Request
 }
 
 // SetParameter sets the parameter value.
+// The parameters are shared by the requests derived from the same request, the requests dispatched by ServeMux always have parameters.
+// The request is replaced by a shallow copy with parameters if it has no parameters,
+// in that case the value is only visible through r.Request (unlike the former implementation mutated the http.Request in place).
 func (r *Request) SetParameter(key interface{}, value interface{}) {
-	body := r.Request.Body.(*requestBody)
-	body.Parameters[key] = value
+	params := parametersOf(r.Request)
+	if params == nil {
+		r.Request = withParameters(r.Request)
+		params = parametersOf(r.Request)
+	}
+	params[key] = value
 }
 
 // Parameter get the parameter value.
 func (r *Request) Parameter(key interface{}) interface{} {
-	body := r.Request.Body.(*requestBody)
-	return body.Parameters[key]
+	return parametersOf(r.Request)[key]
 }
 
 // Parameters returns the all parameters.
 func (r *Request) Parameters() map[interface{}]interface{} {
-	body := r.Request.Body.(*requestBody)
-	return body.Parameters
+	params := parametersOf(r.Request)
+	if params == nil {
+		return make(map[interface{}]interface{})
+	}
+
+	return params
 }
 
 // Method returns the http method.
@@ -53,6 +57,9 @@ func (r Request) Method() string {
 
 // IPAddress returns the client ip address.
 // It resolves X-Real-IP and X-Forwarded-For in header.
+//
+// Deprecated: the headers are supplied by client and trusted from any source (the leftmost X-Forwarded-For can be forged),
+// use the real IP middleware (github.com/wayn3h0/gop/http/middleware.NewRealIP) with trusted proxies and its RealIP func instead.
 func (r Request) IPAddress() string {
 	ip := r.Request.Header.Get("X-Real-IP")
 	if len(ip) > 0 {
@@ -119,13 +126,13 @@ func (r Request) ReadString() (string, error) {
 }
 
 // NewRequest returns a new request.
+// The request is a shallow copy of r with parameters in context if r has no parameters,
+// so the parameters set on it are not visible through r (the former implementation mutated r in place).
+// The requests dispatched by ServeMux always have parameters, the pattern
+// NewRequest(req).SetParameter(key, value); next.ServeHTTP(rw, req) works in middlewares.
 func NewRequest(r *http.Request) *Request {
-	if _, ok := r.Body.(*requestBody); !ok {
-		body := &requestBody{
-			ReadCloser: r.Body,
-			Parameters: make(map[interface{}]interface{}),
-		}
-		r.Body = body
+	if parametersOf(r) == nil {
+		r = withParameters(r)
 	}
 
 	return &Request{
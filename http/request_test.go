@@ -1,7 +1,11 @@
 package http
 
 import (
+	"context"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	testing2 "github.com/wayn3h0/gop/testing"
@@ -22,3 +26,31 @@ func TestRequest(t *testing.T) {
 	testing2.ExpectEqual(t, req.Parameter(key2), value2)
 	testing2.ExpectEqual(t, len(req.Parameters()), 2)
 }
+
+func TestParams(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://localhost", strings.NewReader("body"))
+	r = WithParams(r, Param{Key: "id", Value: "42"}, Param{Key: "uuid", Value: "6ba7b810-9dad-11d1-80b4-00c04fd430c8"}, Param{Key: "name", Value: "bob"})
+
+	// wrapped body does not lose parameters
+	r.Body = ioutil.NopCloser(r.Body)
+	r = r.WithContext(context.WithValue(r.Context(), struct{}{}, true))
+
+	testing2.ExpectEqual(t, ParamString(r, "name"), "bob")
+	testing2.ExpectEqual(t, ParamString(r, "unknown"), "")
+	id, err := ParamInt(r, "id")
+	testing2.ExpectEqual(t, err, nil)
+	testing2.ExpectEqual(t, id, 42)
+	_, err = ParamInt(r, "name")
+	testing2.ExpectNotEqual(t, err, nil)
+	u, err := ParamUUID(r, "uuid")
+	testing2.ExpectEqual(t, err, nil)
+	testing2.ExpectEqual(t, u.String(), "6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	_, err = ParamUUID(r, "id")
+	testing2.ExpectNotEqual(t, err, nil)
+
+	// compatibility
+	testing2.ExpectEqual(t, NewRequest(r).Parameter("name"), "bob")
+	testing2.ExpectEqual(t, (&Request{Request: httptest.NewRequest("GET", "/", nil)}).Parameter("name"), nil)
+	body, _ := NewRequest(r).ReadString()
+	testing2.ExpectEqual(t, body, "body")
+}
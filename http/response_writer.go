@@ -12,6 +12,33 @@ import (
 // ResponseWriter represents a wrapper for http.ResponseWriter.
 type ResponseWriter struct {
 	http.ResponseWriter
+	request *http.Request
+	indent  string
+	jsonp   string
+}
+
+// ResponseOption represents an option of response writer.
+type ResponseOption func(*ResponseWriter)
+
+// WithRequest sets the request for content negotiation.
+func WithRequest(req *http.Request) ResponseOption {
+	return func(r *ResponseWriter) {
+		r.request = req
+	}
+}
+
+// WithIndent sets the indent for pretty-printing the negotiated JSON and XML.
+func WithIndent(indent string) ResponseOption {
+	return func(r *ResponseWriter) {
+		r.indent = indent
+	}
+}
+
+// WithJSONP enables JSONP for the negotiated JSON, the callback is read from the query parameter by given name (e.g. "callback").
+func WithJSONP(param string) ResponseOption {
+	return func(r *ResponseWriter) {
+		r.jsonp = param
+	}
 }
 
 // WriteJSON writes the obj as JSON into the response body.
@@ -68,8 +95,13 @@ func (r ResponseWriter) WriteString(str string) (int, error) {
 }
 
 // NewResponseWriter returns a new response writer.
-func NewResponseWriter(rw http.ResponseWriter) *ResponseWriter {
-	return &ResponseWriter{
+func NewResponseWriter(rw http.ResponseWriter, options ...ResponseOption) *ResponseWriter {
+	r := &ResponseWriter{
 		ResponseWriter: rw,
 	}
+	for _, option := range options {
+		option(r)
+	}
+
+	return r
 }
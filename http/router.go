@@ -2,6 +2,7 @@ package http
 
 import (
 	"net/http"
+	"strings"
 )
 
 // ServeMux represents a HTTP request multiplexer.
@@ -13,8 +14,12 @@ type ServeMux interface {
 }
 
 // Router represents a HTTP router.
+// The middlewares are applied when the handler is registered, so the middleware used by Use only wraps the handlers registered after it.
+// It also applies to the handlers registered in groups after it's used, even if the groups were created before.
 type Router struct {
 	mux         ServeMux
+	parent      *Router
+	prefix      string
 	middlewares []Middleware
 }
 
@@ -30,18 +35,37 @@ func (r *Router) Use(middleware Middleware) *Router {
 	return r
 }
 
+// Group returns a sub-router shares the mux, the handlers registered in it are prefixed and wrapped by given middlewares.
+// The middlewares are applied in order: the ones of root router, the ones of groups from outer to inner, then the ones of handler.
+func (r *Router) Group(prefix string, middlewares ...Middleware) *Router {
+	return &Router{
+		mux:         r.mux,
+		parent:      r,
+		prefix:      strings.TrimRight(prefix, "/"),
+		middlewares: append([]Middleware(nil), middlewares...),
+	}
+}
+
 // Handle registers the handler for handling request matches given method and path pattern.
 func (r *Router) Handle(method string, path string, handler http.Handler, middlewares ...Middleware) {
 	// middlwares for given handler
-	for i := len(middlewares) - 1; i >= 0; i++ {
+	for i := len(middlewares) - 1; i >= 0; i-- {
 		handler = middlewares[i].Wrap(handler)
 	}
-	// global middlewares for all handlers
-	for i := len(r.middlewares) - 1; i >= 0; i-- {
-		handler = r.middlewares[i].Wrap(handler)
+	// middlewares of the router and its parents, they also wrap the automatic OPTIONS answer of the path
+	options := answerOptions
+	for router := r; router != nil; router = router.parent {
+		for i := len(router.middlewares) - 1; i >= 0; i-- {
+			handler = router.middlewares[i].Wrap(handler)
+			options = router.middlewares[i].Wrap(options)
+		}
+		path = router.prefix + path
 	}
 
 	r.mux.Handle(method, path, handler)
+	if mux, ok := r.mux.(*TreeMux); ok {
+		mux.handleOptions(path, options)
+	}
 }
 
 // Get is short for Handle (handle GET request).
@@ -75,12 +99,17 @@ func (r *Router) Head(path string, handler http.Handler, middlewares ...Middlewa
 }
 
 // Options is short for Handle (handle OPTIONS request).
+// The TreeMux answers OPTIONS requests with the Allow header automatically (wrapped by the middlewares of router), it's needed only for customizing the response.
 func (r *Router) Options(path string, handler http.Handler, middlewares ...Middleware) {
 	r.Handle("OPTIONS", path, handler, middlewares...)
 }
 
-// NewRouter returns a new router.
+// NewRouter returns a new router, the TreeMux used if mux is nil.
 func NewRouter(mux ServeMux) *Router {
+	if mux == nil {
+		mux = NewTreeMux()
+	}
+
 	return &Router{
 		mux: mux,
 	}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	testing2 "github.com/wayn3h0/gop/testing"
+)
+
+type serveMux map[string]http.Handler
+
+func (m serveMux) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if h, ok := m[req.Method+" "+req.URL.Path]; ok {
+		h.ServeHTTP(rw, req)
+		return
+	}
+
+	http.NotFound(rw, req)
+}
+
+func (m serveMux) Handle(method, path string, handler http.Handler) {
+	m[method+" "+path] = handler
+}
+
+// trace returns a middleware appends the name to response header.
+func trace(name string) Middleware {
+	return MiddlewareFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.Header().Add("X-Trace", name)
+			next.ServeHTTP(rw, req)
+		})
+	})
+}
+
+func TestRouterGroup(t *testing.T) {
+	mux := make(serveMux)
+	router := NewRouter(mux).Use(trace("root"))
+	api := router.Group("/api/v1/", trace("api"))
+	users := api.Group("/users", trace("users"))
+	router.Use(trace("late")) // applies to the handlers registered after it
+
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+	router.Get("/", handler)
+	api.Post("/login", handler)
+	users.Get("/:id", handler, trace("route"))
+
+	tests := []struct {
+		Method string
+		Path   string
+		Trace  []string
+	}{
+		{"GET", "/", []string{"root", "late"}},
+		{"POST", "/api/v1/login", []string{"root", "late", "api"}},
+		{"GET", "/api/v1/users/:id", []string{"root", "late", "api", "users", "route"}},
+	}
+	for i, test := range tests {
+		rw := httptest.NewRecorder()
+		router.ServeHTTP(rw, httptest.NewRequest(test.Method, test.Path, nil))
+		testing2.ExpectEqualL(t, rw.Code, http.StatusOK, i)
+		testing2.ExpectEqualL(t, rw.Header()["X-Trace"], test.Trace, i)
+	}
+}
+
+func TestRouterHandleMiddlewares(t *testing.T) {
+	router := NewRouter(serveMux{})
+	router.Use(trace("global"))
+	router.Get("/", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}), trace("first"), trace("second"))
+
+	rw := httptest.NewRecorder()
+	router.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	testing2.ExpectEqual(t, rw.Header()["X-Trace"], []string{"global", "first", "second"})
+}
+
+func TestRouterSetParameter(t *testing.T) {
+	router := NewRouter(nil)
+	router.Use(MiddlewareFunc(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			NewRequest(req).SetParameter("user", "bob")
+			next.ServeHTTP(rw, req)
+		})
+	}))
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		user, _ := NewRequest(req).Parameter("user").(string)
+		rw.Write([]byte(user + ParamString(req, "id")))
+	})
+	router.Get("/users", handler) // no route parameters
+	router.Get("/users/:id", handler)
+
+	for i, c := range [][2]string{{"/users", "bob"}, {"/users/1", "bob1"}} {
+		rw := httptest.NewRecorder()
+		router.ServeHTTP(rw, httptest.NewRequest("GET", c[0], nil))
+		testing2.ExpectEqualL(t, rw.Body.String(), c[1], i)
+	}
+}
+
+func TestRouterOptions(t *testing.T) {
+	router := NewRouter(nil).Use(trace("root"))
+	api := router.Group("/api", trace("api"))
+	api.Get("/users", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}), trace("route"))
+
+	rw := httptest.NewRecorder()
+	router.ServeHTTP(rw, httptest.NewRequest("OPTIONS", "/api/users", nil))
+	testing2.ExpectEqual(t, rw.Code, http.StatusNoContent)
+	testing2.ExpectEqual(t, rw.Header().Get("Allow"), "GET, HEAD, OPTIONS")
+	testing2.ExpectEqual(t, rw.Header()["X-Trace"], []string{"root", "api"}) // the middlewares of handler are not applied
+}
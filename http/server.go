@@ -0,0 +1,451 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/wayn3h0/gop/errors"
+	"github.com/wayn3h0/gop/log"
+	"github.com/wayn3h0/gop/sql"
+)
+
+// Default timeouts of server.
+const (
+	DefaultReadTimeout     = 30 * time.Second
+	DefaultWriteTimeout    = 30 * time.Second
+	DefaultIdleTimeout     = 120 * time.Second
+	DefaultShutdownTimeout = 30 * time.Second
+	DefaultHookTimeout     = 10 * time.Second
+)
+
+// Default paths of health endpoints.
+const (
+	DefaultHealthPath = "/healthz"
+	DefaultReadyPath  = "/readyz"
+)
+
+// The states of server.
+const (
+	stateStarting int32 = iota
+	stateReady
+	stateDraining
+	stateStopped
+)
+
+// Server represents a HTTP server with graceful shutdown.
+//
+// The server shuts down on SIGINT or SIGTERM: the readiness endpoint reports 503 (Service Unavailable) during the shutdown delay,
+// then the listener is closed and the in-flight requests are drained until the shutdown timeout,
+// at last the shutdown hooks are called within the hook timeout, which starts after the draining.
+// On SIGHUP, the server hands off the listener to a new process of the same executable (zero-downtime restart) then shuts down.
+// The listener inherited by socket activation (LISTEN_FDS) is used if present.
+type Server struct {
+	server          *http.Server
+	logger          *log.Logger
+	shutdownTimeout time.Duration
+	shutdownDelay   time.Duration
+	hookTimeout     time.Duration
+	healthPath      string
+	readyPath       string
+
+	state    int32
+	mutex    sync.Mutex
+	listener net.Listener
+	hooks    []func(context.Context) error
+	done     chan struct{}
+	err      error
+}
+
+// ServerOption represents an option of server.
+type ServerOption func(*Server)
+
+// WithTimeouts sets the read, write and idle timeouts of connections.
+func WithTimeouts(read, write, idle time.Duration) ServerOption {
+	return func(s *Server) {
+		s.server.ReadTimeout = read
+		s.server.WriteTimeout = write
+		s.server.IdleTimeout = idle
+	}
+}
+
+// WithShutdownTimeout sets the deadline of draining in-flight requests, the connections are closed forcibly after it.
+func WithShutdownTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.shutdownTimeout = timeout
+	}
+}
+
+// WithHookTimeout sets the deadline of calling the shutdown hooks, it starts after the requests drained.
+func WithHookTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.hookTimeout = timeout
+	}
+}
+
+// WithShutdownDelay sets the delay before closing the listener, the readiness endpoint reports 503 in the meantime for load balancers.
+func WithShutdownDelay(delay time.Duration) ServerOption {
+	return func(s *Server) {
+		s.shutdownDelay = delay
+	}
+}
+
+// WithHealthPaths sets the paths of health (liveness) and readiness endpoints, empty path disables the endpoint.
+func WithHealthPaths(health, ready string) ServerOption {
+	return func(s *Server) {
+		s.healthPath = health
+		s.readyPath = ready
+	}
+}
+
+// WithListener sets the listener instead of listening on the address.
+func WithListener(listener net.Listener) ServerOption {
+	return func(s *Server) {
+		s.listener = listener
+	}
+}
+
+// WithServerLogger sets the logger of server.
+func WithServerLogger(logger *log.Logger) ServerOption {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// NewServer returns a new server serves the handler (e.g. Router) on the address.
+func NewServer(addr string, handler http.Handler, options ...ServerOption) *Server {
+	s := &Server{
+		server: &http.Server{
+			Addr:         addr,
+			ReadTimeout:  DefaultReadTimeout,
+			WriteTimeout: DefaultWriteTimeout,
+			IdleTimeout:  DefaultIdleTimeout,
+		},
+		logger:          log.DefaultLogger,
+		shutdownTimeout: DefaultShutdownTimeout,
+		hookTimeout:     DefaultHookTimeout,
+		healthPath:      DefaultHealthPath,
+		readyPath:       DefaultReadyPath,
+		done:            make(chan struct{}),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	s.server.Handler = s.wrap(handler)
+
+	return s
+}
+
+// wrap returns the handler serves the health endpoints before the handler.
+func (s *Server) wrap(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch {
+		case len(s.healthPath) > 0 && req.URL.Path == s.healthPath:
+			s.Health().ServeHTTP(rw, req)
+		case len(s.readyPath) > 0 && req.URL.Path == s.readyPath:
+			s.Readiness().ServeHTTP(rw, req)
+		default:
+			handler.ServeHTTP(rw, req)
+		}
+	})
+}
+
+// Health returns the liveness endpoint, it responds with 200 (OK) until the server stopped.
+func (s *Server) Health() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Cache-Control", "no-store")
+		if atomic.LoadInt32(&s.state) == stateStopped {
+			http.Error(rw, "stopped", http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(rw, "ok", http.StatusOK)
+	})
+}
+
+// Readiness returns the readiness endpoint, it responds with 200 (OK) only if the server is serving and not shutting down.
+func (s *Server) Readiness() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Cache-Control", "no-store")
+		switch atomic.LoadInt32(&s.state) {
+		case stateReady:
+			http.Error(rw, "ready", http.StatusOK)
+		case stateStarting:
+			http.Error(rw, "starting", http.StatusServiceUnavailable)
+		default:
+			http.Error(rw, "shutting down", http.StatusServiceUnavailable)
+		}
+	})
+}
+
+// Ready reports whether the server is serving and not shutting down.
+func (s *Server) Ready() bool {
+	return atomic.LoadInt32(&s.state) == stateReady
+}
+
+// Addr returns the address of listener, it returns nil if not listening.
+func (s *Server) Addr() net.Addr {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.listener == nil {
+		return nil
+	}
+
+	return s.listener.Addr()
+}
+
+// OnShutdown registers the hook called after the requests drained, the hooks are called in reverse order of registration.
+// The ctx of hooks is bounded by the hook timeout, so a slow draining does not leave the hooks without time.
+func (s *Server) OnShutdown(hook func(ctx context.Context) error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.hooks = append(s.hooks, hook)
+}
+
+// StopScheduler stops the scheduler (e.g. *jobs.Scheduler) on shutdown.
+// It should be registered after CloseDatabase, so the scheduler stops before the database closes.
+func (s *Server) StopScheduler(scheduler interface{ Stop() }) {
+	s.OnShutdown(func(ctx context.Context) error {
+		stopped := make(chan struct{})
+		go func() {
+			scheduler.Stop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "http: could not stop scheduler before deadline")
+		}
+	})
+}
+
+// CloseDatabase closes the database on shutdown.
+func (s *Server) CloseDatabase(db sql.Database) {
+	s.OnShutdown(func(ctx context.Context) error {
+		err := db.Close()
+		if err != nil {
+			return errors.Wrap(err, "http: could not close database")
+		}
+
+		return nil
+	})
+}
+
+// inherited returns the listener inherited by socket activation (LISTEN_FDS), it returns nil if absent.
+func inherited() (net.Listener, error) {
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 1 {
+		return nil, nil
+	}
+	if pid := os.Getenv("LISTEN_PID"); len(pid) > 0 && pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_PID")
+
+	f := os.NewFile(3, "listener") // the first passed file descriptor
+	defer f.Close()
+	listener, err := net.FileListener(f)
+	if err != nil {
+		return nil, errors.Wrap(err, "http: could not use inherited listener")
+	}
+
+	return listener, nil
+}
+
+// listen returns the listener set by option, inherited, or listening on the address.
+func (s *Server) listen() (net.Listener, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.listener != nil {
+		return s.listener, nil
+	}
+
+	listener, err := inherited()
+	if err != nil {
+		return nil, err
+	}
+	if listener == nil {
+		addr := s.server.Addr
+		if len(addr) == 0 {
+			addr = ":http"
+		}
+		listener, err = net.Listen("tcp", addr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "http: could not listen on %s", addr)
+		}
+	}
+	s.listener = listener
+
+	return listener, nil
+}
+
+// ListenAndServe serves the requests until the server is shut down by signal or Shutdown func.
+// It returns nil after the graceful shutdown, or the error of serving or shutdown.
+func (s *Server) ListenAndServe() error {
+	listener, err := s.listen()
+	if err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- s.server.Serve(listener)
+	}()
+	atomic.CompareAndSwapInt32(&s.state, stateStarting, stateReady) // not ready if shutting down already
+	s.logger.Infof("http: server is listening on %s", listener.Addr())
+
+	for {
+		select {
+		case err := <-served:
+			if err != http.ErrServerClosed && atomic.CompareAndSwapInt32(&s.state, stateReady, stateStopped) { // failed
+				s.err = errors.Wrap(err, "http: could not serve")
+				close(s.done)
+				return s.err
+			}
+			<-s.done
+			return s.err
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				err := s.handoff(listener)
+				if err != nil {
+					s.logger.Errorf("http: could not hand off listener: %s", errors.TreeMessage(err))
+					continue
+				}
+			}
+			s.logger.Infof("http: server is shutting down on %s", sig)
+			go s.Shutdown(context.Background())
+		case <-s.done:
+			return s.err
+		}
+	}
+}
+
+// handoff starts a new process of the same executable inheriting the listener.
+func (s *Server) handoff(listener net.Listener) error {
+	filer, ok := listener.(interface{ File() (*os.File, error) })
+	if !ok {
+		return errors.Newf("http: listener %T cannot be handed off", listener)
+	}
+	f, err := filer.File()
+	if err != nil {
+		return errors.Wrap(err, "http: could not get file of listener")
+	}
+	defer f.Close()
+
+	executable, err := os.Executable()
+	if err != nil {
+		return errors.Wrap(err, "http: could not get executable")
+	}
+	var env []string
+	for _, v := range os.Environ() {
+		if !strings.HasPrefix(v, "LISTEN_FDS=") && !strings.HasPrefix(v, "LISTEN_PID=") {
+			env = append(env, v)
+		}
+	}
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Env = append(env, "LISTEN_FDS=1")
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{f}
+	err = cmd.Start()
+	if err != nil {
+		return errors.Wrap(err, "http: could not start new process")
+	}
+	s.logger.Infof("http: listener is handed off to process %d", cmd.Process.Pid)
+
+	return nil
+}
+
+// ShutdownErrors represents the errors of graceful shutdown, the error of draining comes first, then the ones of hooks.
+type ShutdownErrors []error
+
+// Error implements builtin.error interface.
+func (e ShutdownErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+
+	return "http: could not shut down server gracefully:\n" + strings.Join(messages, "\n")
+}
+
+// Unwrap returns the errors.
+func (e ShutdownErrors) Unwrap() []error {
+	return e
+}
+
+// Shutdown shuts down the server gracefully, the ctx limits the draining in addition to the shutdown timeout,
+// the hooks are limited by the hook timeout only.
+// It returns ShutdownErrors if the draining or any hook failed.
+// It's safe to call it more than once, the later calls wait for the first one.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&s.state, stateReady, stateDraining) &&
+		!atomic.CompareAndSwapInt32(&s.state, stateStarting, stateDraining) {
+		<-s.done
+		return s.err
+	}
+
+	if s.shutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.shutdownDelay+s.shutdownTimeout)
+		defer cancel()
+	}
+
+	if s.shutdownDelay > 0 {
+		select {
+		case <-time.After(s.shutdownDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	var errs ShutdownErrors
+	err := s.server.Shutdown(ctx)
+	if err != nil {
+		s.server.Close() // closes the remaining connections forcibly
+		errs = append(errs, errors.Wrap(err, "http: could not drain requests before deadline"))
+	}
+
+	// the hooks have their own deadline, the draining may have used up ctx
+	hookCtx := context.Background()
+	if s.hookTimeout > 0 {
+		var cancel context.CancelFunc
+		hookCtx, cancel = context.WithTimeout(hookCtx, s.hookTimeout)
+		defer cancel()
+	}
+	s.mutex.Lock()
+	hooks := s.hooks
+	s.mutex.Unlock()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		err := hooks[i](hookCtx)
+		if err != nil {
+			s.logger.Errorf("http: shutdown hook failed: %s", errors.TreeMessage(err))
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		s.err = errs
+	}
+	atomic.StoreInt32(&s.state, stateStopped)
+	close(s.done)
+
+	return s.err
+}
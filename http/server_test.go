@@ -0,0 +1,187 @@
+package http
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/wayn3h0/gop/jobs"
+	"github.com/wayn3h0/gop/log"
+	"github.com/wayn3h0/gop/sql"
+	testing2 "github.com/wayn3h0/gop/testing"
+)
+
+// get returns the status code and body of response.
+func get(url string) (int, string, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+
+	return resp.StatusCode, string(body), err
+}
+
+func TestServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	testing2.AssertEqual(t, err, nil)
+	started := make(chan bool)
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/slow" {
+			started <- true
+			time.Sleep(100 * time.Millisecond)
+		}
+		rw.Write([]byte("done"))
+	})
+	s := NewServer("", handler, WithListener(listener), WithShutdownDelay(50*time.Millisecond), WithServerLogger(log.NewLogger(ioutil.Discard, "")))
+
+	var order []string
+	scheduler := jobs.NewScheduler()
+	scheduler.Start()
+	s.StopScheduler(scheduler)
+	s.OnShutdown(func(ctx context.Context) error {
+		order = append(order, "second")
+		return nil
+	})
+	s.OnShutdown(func(ctx context.Context) error {
+		order = append(order, "first")
+		return nil
+	})
+
+	served := make(chan error)
+	go func() {
+		served <- s.ListenAndServe()
+	}()
+	url := "http://" + listener.Addr().String()
+	for !s.Ready() {
+		time.Sleep(time.Millisecond)
+	}
+
+	status, body, err := get(url + DefaultHealthPath)
+	testing2.AssertEqual(t, err, nil)
+	testing2.ExpectEqual(t, status, http.StatusOK)
+	testing2.ExpectEqual(t, body, "ok\n")
+	status, _, _ = get(url + DefaultReadyPath)
+	testing2.ExpectEqual(t, status, http.StatusOK)
+
+	// in-flight request is drained
+	slow := make(chan string)
+	go func() {
+		_, body, _ := get(url + "/slow")
+		slow <- body
+	}()
+	<-started
+	shutdown := make(chan error)
+	go func() {
+		shutdown <- s.Shutdown(context.Background())
+	}()
+	time.Sleep(10 * time.Millisecond)
+	testing2.ExpectEqual(t, s.Ready(), false)
+	rw := httptest.NewRecorder()
+	s.Readiness().ServeHTTP(rw, httptest.NewRequest("GET", DefaultReadyPath, nil))
+	testing2.ExpectEqual(t, rw.Code, http.StatusServiceUnavailable)
+
+	testing2.ExpectEqual(t, <-slow, "done")
+	testing2.ExpectEqual(t, <-shutdown, nil)
+	testing2.ExpectEqual(t, <-served, nil)
+	testing2.ExpectEqual(t, order, []string{"first", "second"})
+
+	_, _, err = get(url)
+	testing2.ExpectNotEqual(t, err, nil)
+	testing2.ExpectEqual(t, s.Shutdown(context.Background()), nil) // again
+}
+
+// database represents a fake database records whether it was closed.
+type database struct {
+	sql.Database
+	closed bool
+}
+
+// Close implements sql.Database interface.
+func (d *database) Close() error {
+	d.closed = true
+	return nil
+}
+
+func TestServerHooks(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	testing2.AssertEqual(t, err, nil)
+	started, release := make(chan bool), make(chan bool)
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		started <- true
+		<-release
+	})
+	s := NewServer("", handler, WithListener(listener), WithShutdownTimeout(20*time.Millisecond), WithHookTimeout(time.Second), WithServerLogger(log.NewLogger(ioutil.Discard, "")))
+
+	db := &database{}
+	s.CloseDatabase(db)
+	s.StopScheduler(jobs.NewScheduler()) // not running
+	var remaining time.Duration
+	s.OnShutdown(func(ctx context.Context) error {
+		deadline, _ := ctx.Deadline()
+		remaining = time.Until(deadline)
+		return ctx.Err()
+	})
+
+	go s.ListenAndServe()
+	for !s.Ready() {
+		time.Sleep(time.Millisecond)
+	}
+	go get("http://" + listener.Addr().String())
+	<-started
+	defer close(release)
+
+	// the draining uses up the shutdown timeout, the hooks still have their own deadline
+	err = s.Shutdown(context.Background())
+	errs, ok := err.(ShutdownErrors)
+	testing2.AssertEqual(t, ok, true)
+	testing2.AssertEqual(t, len(errs), 1)
+	testing2.ExpectEqual(t, strings.Contains(errs[0].Error(), "drain"), true)
+	testing2.ExpectEqual(t, strings.Contains(err.Error(), "scheduler"), false)
+	testing2.ExpectEqual(t, remaining > 500*time.Millisecond, true)
+	testing2.ExpectEqual(t, db.closed, true)
+}
+
+func TestServerShutdownBeforeReady(t *testing.T) {
+	s := NewServer("127.0.0.1:0", http.NotFoundHandler(), WithServerLogger(log.NewLogger(ioutil.Discard, "")))
+	testing2.ExpectEqual(t, s.Shutdown(context.Background()), nil)
+	testing2.ExpectEqual(t, s.ListenAndServe(), nil)
+	testing2.ExpectEqual(t, s.Ready(), false)
+}
+
+func TestServerSignal(t *testing.T) {
+	s := NewServer("127.0.0.1:0", http.NotFoundHandler(), WithShutdownTimeout(10*time.Millisecond), WithServerLogger(log.NewLogger(ioutil.Discard, "")))
+	hooked := false
+	s.OnShutdown(func(ctx context.Context) error {
+		hooked = true
+		return nil
+	})
+
+	served := make(chan error)
+	go func() {
+		served <- s.ListenAndServe()
+	}()
+	for !s.Ready() {
+		time.Sleep(time.Millisecond)
+	}
+	testing2.ExpectNotEqual(t, s.Addr(), nil)
+
+	process, err := os.FindProcess(os.Getpid())
+	testing2.AssertEqual(t, err, nil)
+	testing2.AssertEqual(t, process.Signal(syscall.SIGTERM), nil)
+	select {
+	case err := <-served:
+		testing2.ExpectEqual(t, err, nil)
+	case <-time.After(time.Second):
+		t.Fatal("server is not shut down by signal")
+	}
+	testing2.ExpectEqual(t, hooked, true)
+}
@@ -0,0 +1,434 @@
+package http
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/wayn3h0/gop/errors"
+)
+
+// node represents a node of radix tree.
+type node struct {
+	prefix   string         // static text of static node
+	name     string         // name of param or catch-all node
+	expr     string         // regular expression of param node
+	regexp   *regexp.Regexp // compiled constraint of param node
+	pattern  string
+	statics  []*node
+	params   []*node // the constrained params are ahead of others
+	catchAll *node
+	handlers map[string]http.Handler
+	options  http.Handler // answers the OPTIONS request automatically, it's wrapped by the middlewares of Router
+}
+
+// commonPrefix returns the length of common prefix.
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+
+	return i
+}
+
+// insertStatic inserts the static text to the children, it returns the node ends with the text.
+func (n *node) insertStatic(text string) *node {
+	for _, child := range n.statics {
+		i := commonPrefix(child.prefix, text)
+		if i == 0 {
+			continue
+		}
+
+		if i < len(child.prefix) { // splits the child
+			split := &node{
+				prefix:   child.prefix[i:],
+				pattern:  child.pattern,
+				statics:  child.statics,
+				params:   child.params,
+				catchAll: child.catchAll,
+				handlers: child.handlers,
+			}
+			*child = node{
+				prefix:  child.prefix[:i],
+				statics: []*node{split},
+			}
+		}
+		if i == len(text) {
+			return child
+		}
+
+		return child.insertStatic(text[i:])
+	}
+
+	child := &node{
+		prefix: text,
+	}
+	n.statics = append(n.statics, child)
+
+	return child
+}
+
+// insertParam inserts the param to the children, it returns the param node.
+func (n *node) insertParam(name, expr string) (*node, error) {
+	for _, child := range n.params {
+		if child.name == name && child.expr == expr {
+			return child, nil
+		}
+	}
+
+	child := &node{
+		name: name,
+		expr: expr,
+	}
+	if len(expr) > 0 {
+		r, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, err
+		}
+		child.regexp = r
+		// the constrained params are ahead of others
+		i := 0
+		for i < len(n.params) && n.params[i].regexp != nil {
+			i++
+		}
+		n.params = append(n.params[:i], append([]*node{child}, n.params[i:]...)...)
+	} else {
+		n.params = append(n.params, child)
+	}
+
+	return child, nil
+}
+
+// closing returns the end of braced param (the braces in regular expression are counted), or -1 if it's not closed.
+func closing(str string) int {
+	depth := 0
+	for i := 0; i < len(str); i++ {
+		switch str[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+
+	return -1
+}
+
+// insert inserts the pattern to the tree, it returns the node ends with the pattern.
+func (n *node) insert(pattern string) (*node, error) {
+	current := n
+	rest := pattern
+	for len(rest) > 0 {
+		// static text until next param or catch-all
+		i := strings.IndexAny(rest, ":{*")
+		for i > 0 && rest[i-1] != '/' {
+			next := strings.IndexAny(rest[i+1:], ":{*")
+			if next < 0 {
+				i = -1
+				break
+			}
+			i += next + 1
+		}
+		if i < 0 {
+			return current.insertStatic(rest), nil
+		}
+		if i > 0 {
+			current = current.insertStatic(rest[:i])
+			rest = rest[i:]
+		}
+
+		// param or catch-all segment
+		end := strings.IndexByte(rest, '/')
+		if rest[0] == '{' {
+			end = closing(rest)
+			if end < 0 {
+				return nil, errors.Newf("http: param of pattern %s is not closed", pattern)
+			}
+		} else if end < 0 {
+			end = len(rest)
+		}
+		segment := rest[:end]
+		rest = rest[end:]
+
+		switch {
+		case segment[0] == '*':
+			if len(rest) > 0 {
+				return nil, errors.Newf("http: catch-all of pattern %s must be at the end", pattern)
+			}
+			if current.catchAll == nil {
+				current.catchAll = &node{
+					name: segment[1:],
+				}
+			}
+			return current.catchAll, nil
+		case segment[0] == ':':
+			current, _ = current.insertParam(segment[1:], "")
+		default: // {name} or {name:regexp}
+			body := segment[1 : len(segment)-1]
+			name, expr := body, ""
+			if i := strings.IndexByte(body, ':'); i >= 0 {
+				name, expr = body[:i], body[i+1:]
+			}
+			var err error
+			current, err = current.insertParam(name, expr)
+			if err != nil {
+				return nil, errors.Wrapf(err, "http: could not compile regular expression of pattern %s", pattern)
+			}
+		}
+		if len(current.name) == 0 {
+			return nil, errors.Newf("http: name of param in pattern %s is empty", pattern)
+		}
+	}
+
+	return current, nil
+}
+
+// handles reports whether the node has the handler for method (any method if it's empty), the HEAD is handled by GET.
+func (n *node) handles(method string) bool {
+	if len(method) == 0 {
+		return n.handlers != nil
+	}
+	if _, ok := n.handlers[method]; ok {
+		return true
+	}
+	if method == "HEAD" {
+		_, ok := n.handlers["GET"]
+		return ok
+	}
+
+	return false
+}
+
+// find returns the node matches the path and handles the method (any method if it's empty), the static nodes take priority over params, and params over catch-all.
+// It backtracks to the siblings if the matched node doesn't handle the method.
+func (n *node) find(path, method string, params *[]Param) *node {
+	if len(path) == 0 {
+		if n.handles(method) {
+			return n
+		}
+		if n.catchAll != nil && n.catchAll.handles(method) {
+			*params = append(*params, Param{Key: n.catchAll.name})
+			return n.catchAll
+		}
+		return nil
+	}
+
+	for _, child := range n.statics {
+		if strings.HasPrefix(path, child.prefix) {
+			if found := child.find(path[len(child.prefix):], method, params); found != nil {
+				return found
+			}
+		}
+	}
+
+	if len(n.params) > 0 {
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		if segment := path[:end]; len(segment) > 0 {
+			for _, child := range n.params {
+				if child.regexp != nil && !child.regexp.MatchString(segment) {
+					continue
+				}
+				size := len(*params)
+				*params = append(*params, Param{Key: child.name, Value: segment})
+				if found := child.find(path[end:], method, params); found != nil {
+					return found
+				}
+				*params = (*params)[:size]
+			}
+		}
+	}
+
+	if n.catchAll != nil && n.catchAll.handles(method) {
+		*params = append(*params, Param{Key: n.catchAll.name, Value: path})
+		return n.catchAll
+	}
+
+	return nil
+}
+
+// TreeMux represents a HTTP request multiplexer based on radix tree.
+// The pattern supports:
+//
+//	/users/new             static path
+//	/users/:id             named param matches a segment
+//	/users/{id}            named param (same as :id)
+//	/users/{id:[0-9]+}     named param constrained by regular expression
+//	/files/*path           catch-all matches the rest of path (must be at the end)
+//
+// The static path takes priority over params, the constrained params over others, and the params over catch-all.
+// The lower priority patterns are tried if the matched one has no handler for the method, it responds with 405 only if none has.
+// The params can be retrieved by ParamString, ParamInt and ParamUUID funcs.
+type TreeMux struct {
+	root *node
+
+	// RedirectTrailingSlash redirects the request to the path with (or without) the trailing slash if only the other one is registered.
+	RedirectTrailingSlash bool
+
+	// NotFound handles the request matches no pattern, http.NotFound used if nil.
+	NotFound http.Handler
+
+	// MethodNotAllowed handles the request matches a pattern but no method, it responds with 405 if nil.
+	// The Allow header is set before it's called.
+	MethodNotAllowed http.Handler
+}
+
+// Register registers the handler for the given pattern (method and path).
+// It returns an error if the pattern is invalid (e.g. the regular expression of param cannot be compiled) or has been registered for the method.
+func (m *TreeMux) Register(method, path string, handler http.Handler) error {
+	if len(path) == 0 || path[0] != '/' {
+		return errors.Newf("http: path of pattern %s must begin with /", path)
+	}
+	if handler == nil {
+		return errors.Newf("http: handler of pattern %s cannot be nil", path)
+	}
+
+	n, err := m.root.insert(path)
+	if err != nil {
+		return err
+	}
+	method = strings.ToUpper(method)
+	if _, ok := n.handlers[method]; ok {
+		return errors.Newf("http: pattern %s %s has been registered", method, path)
+	}
+	if n.handlers == nil {
+		n.handlers = make(map[string]http.Handler)
+	}
+	n.handlers[method] = handler
+	n.pattern = path
+
+	return nil
+}
+
+// Handle registers the handler for the given pattern (method and path).
+// It panics if the pattern cannot be registered, use Register to handle the error.
+// It implements ServeMux interface.
+func (m *TreeMux) Handle(method, path string, handler http.Handler) {
+	if err := m.Register(method, path, handler); err != nil {
+		panic(err)
+	}
+}
+
+// handleOptions sets the handler answers the OPTIONS request of the registered path automatically, the first one is kept.
+// The Router wraps the automatic answer by its middlewares, so they see the preflight requests.
+func (m *TreeMux) handleOptions(path string, handler http.Handler) {
+	n, err := m.root.insert(path)
+	if err != nil || n.options != nil {
+		return
+	}
+	n.options = handler
+}
+
+// answerOptions answers the OPTIONS request with 204, the Allow header is set by TreeMux before it's called.
+var answerOptions http.Handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+	rw.WriteHeader(http.StatusNoContent)
+})
+
+// allow returns the allowed methods of node, the HEAD and OPTIONS are allowed automatically.
+func allow(n *node) string {
+	methods := []string{"OPTIONS"}
+	for method := range n.handlers {
+		if method != "OPTIONS" {
+			methods = append(methods, method)
+		}
+	}
+	if _, ok := n.handlers["GET"]; ok {
+		if _, ok := n.handlers["HEAD"]; !ok {
+			methods = append(methods, "HEAD")
+		}
+	}
+	sort.Strings(methods)
+
+	return strings.Join(methods, ", ")
+}
+
+// ServeHTTP dispatches the request to the handler whose pattern matches the request path.
+// It implements http.Handler interface.
+func (m *TreeMux) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	path := req.URL.Path
+	var params []Param
+	n := m.root.find(path, req.Method, &params)
+	if n == nil {
+		// the path matches, but no pattern handles the method
+		params = nil
+		n = m.root.find(path, "", &params)
+	}
+	if n == nil {
+		if m.RedirectTrailingSlash && m.redirect(rw, req) {
+			return
+		}
+		if m.NotFound != nil {
+			m.NotFound.ServeHTTP(rw, req)
+		} else {
+			http.NotFound(rw, req)
+		}
+		return
+	}
+
+	handler, ok := n.handlers[req.Method]
+	if !ok && req.Method == "HEAD" {
+		handler, ok = n.handlers["GET"]
+	}
+	if !ok {
+		rw.Header().Set("Allow", allow(n))
+		switch {
+		case req.Method == "OPTIONS":
+			options := n.options
+			if options == nil {
+				options = answerOptions
+			}
+			options.ServeHTTP(rw, WithPattern(WithParams(req, params...), n.pattern))
+		case m.MethodNotAllowed != nil:
+			m.MethodNotAllowed.ServeHTTP(rw, req)
+		default:
+			http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	// the parameters are always attached, so the middlewares can set parameters seen by the handler
+	handler.ServeHTTP(rw, WithPattern(WithParams(req, params...), n.pattern))
+}
+
+// redirect redirects the request to the path with (or without) trailing slash if it matches, it reports whether redirected.
+func (m *TreeMux) redirect(rw http.ResponseWriter, req *http.Request) bool {
+	path := req.URL.Path
+	if path == "/" {
+		return false
+	}
+	if strings.HasSuffix(path, "/") {
+		path = path[:len(path)-1]
+	} else {
+		path += "/"
+	}
+
+	var params []Param
+	if m.root.find(path, "", &params) == nil {
+		return false
+	}
+
+	status := http.StatusMovedPermanently
+	if req.Method != "GET" && req.Method != "HEAD" {
+		status = http.StatusPermanentRedirect
+	}
+	u := *req.URL
+	u.Path = path
+	http.Redirect(rw, req, u.String(), status)
+
+	return true
+}
+
+// NewTreeMux returns a new HTTP request multiplexer based on radix tree, the trailing slash redirection is enabled.
+func NewTreeMux() *TreeMux {
+	return &TreeMux{
+		root:                  &node{},
+		RedirectTrailingSlash: true,
+	}
+}
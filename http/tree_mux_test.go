@@ -0,0 +1,158 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	testing2 "github.com/wayn3h0/gop/testing"
+)
+
+// echo returns a handler writes the name and params of pattern.
+func echo(name string, keys ...string) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, name)
+		for _, key := range keys {
+			fmt.Fprintf(rw, " %s=%s", key, ParamString(req, key))
+		}
+	})
+}
+
+func TestTreeMux(t *testing.T) {
+	mux := NewTreeMux()
+	mux.Handle("GET", "/", echo("root"))
+	mux.Handle("GET", "/users", echo("users"))
+	mux.Handle("POST", "/users", echo("create"))
+	mux.Handle("GET", "/users/new", echo("new"))
+	mux.Handle("GET", "/users/{id:[0-9]+}", echo("user", "id"))
+	mux.Handle("GET", "/users/:name", echo("named", "name"))
+	mux.Handle("GET", "/users/:name/posts/{post}", echo("post", "name", "post"))
+	mux.Handle("GET", "/users/newest", echo("newest"))
+	mux.Handle("GET", "/codes/{code:[A-Z]{2}}", echo("code", "code"))
+	mux.Handle("GET", "/files/*path", echo("file", "path"))
+	mux.Handle("GET", "/docs/", echo("docs"))
+
+	tests := []struct {
+		Method string
+		Path   string
+		Status int
+		Body   string
+	}{
+		{"GET", "/", 200, "root"},
+		{"GET", "/users", 200, "users"},
+		{"POST", "/users", 200, "create"},
+		{"GET", "/users/new", 200, "new"},
+		{"GET", "/users/newest", 200, "newest"},
+		{"GET", "/users/42", 200, "user id=42"},
+		{"GET", "/users/bob", 200, "named name=bob"},
+		{"GET", "/users/42/posts/7", 200, "post name=42 post=7"},
+		{"GET", "/codes/CN", 200, "code code=CN"},
+		{"GET", "/codes/CHN", 404, "404 page not found\n"},
+		{"GET", "/files/a/b.txt", 200, "file path=a/b.txt"},
+		{"GET", "/files/", 200, "file path="},
+		{"HEAD", "/users", 200, "users"},
+		{"DELETE", "/users", 405, "Method Not Allowed\n"},
+		{"OPTIONS", "/users", 204, ""},
+		{"GET", "/unknown", 404, "404 page not found\n"},
+	}
+	for i, test := range tests {
+		rw := httptest.NewRecorder()
+		mux.ServeHTTP(rw, httptest.NewRequest(test.Method, test.Path, nil))
+		testing2.ExpectEqualL(t, rw.Code, test.Status, i)
+		testing2.ExpectEqualL(t, rw.Body.String(), test.Body, i)
+		if test.Status == 405 || test.Status == 204 {
+			testing2.ExpectEqualL(t, rw.Header().Get("Allow"), "GET, HEAD, OPTIONS, POST", i)
+		}
+	}
+}
+
+func TestTreeMuxRedirect(t *testing.T) {
+	mux := NewTreeMux()
+	mux.Handle("GET", "/users", echo("users"))
+	mux.Handle("POST", "/docs/", echo("docs"))
+
+	tests := []struct {
+		Method   string
+		Path     string
+		Status   int
+		Location string
+	}{
+		{"GET", "/users/?page=2", http.StatusMovedPermanently, "/users?page=2"},
+		{"POST", "/docs", http.StatusPermanentRedirect, "/docs/"},
+	}
+	for i, test := range tests {
+		rw := httptest.NewRecorder()
+		mux.ServeHTTP(rw, httptest.NewRequest(test.Method, test.Path, nil))
+		testing2.ExpectEqualL(t, rw.Code, test.Status, i)
+		testing2.ExpectEqualL(t, rw.Header().Get("Location"), test.Location, i)
+	}
+
+	mux.RedirectTrailingSlash = false
+	rw := httptest.NewRecorder()
+	mux.ServeHTTP(rw, httptest.NewRequest("GET", "/users/", nil))
+	testing2.ExpectEqual(t, rw.Code, http.StatusNotFound)
+}
+
+func TestTreeMuxBacktrack(t *testing.T) {
+	mux := NewTreeMux()
+	mux.Handle("GET", "/users/new", echo("new"))
+	mux.Handle("POST", "/users/:id", echo("update", "id"))
+	mux.Handle("PUT", "/users/*path", echo("put", "path"))
+
+	tests := []struct {
+		Method string
+		Path   string
+		Status int
+		Body   string
+		Allow  string
+	}{
+		{"GET", "/users/new", 200, "new", ""},
+		{"POST", "/users/new", 200, "update id=new", ""},
+		{"PUT", "/users/new", 200, "put path=new", ""},
+		{"DELETE", "/users/new", 405, "Method Not Allowed\n", "GET, HEAD, OPTIONS"},
+		{"DELETE", "/users/42", 405, "Method Not Allowed\n", "OPTIONS, POST"},
+	}
+	for i, test := range tests {
+		rw := httptest.NewRecorder()
+		mux.ServeHTTP(rw, httptest.NewRequest(test.Method, test.Path, nil))
+		testing2.ExpectEqualL(t, rw.Code, test.Status, i)
+		testing2.ExpectEqualL(t, rw.Body.String(), test.Body, i)
+		testing2.ExpectEqualL(t, rw.Header().Get("Allow"), test.Allow, i)
+	}
+}
+
+func TestTreeMuxConflict(t *testing.T) {
+	patterns := []string{"users", "/users/{id", "/files/*path/more", "/users/:", "/users/{id:[0-9}"}
+	for i, pattern := range patterns {
+		testing2.ExpectNotEqualL(t, NewTreeMux().Register("GET", pattern, echo("x")), nil, i)
+		func() {
+			defer func() {
+				testing2.ExpectNotEqualL(t, recover(), nil, i)
+			}()
+			NewTreeMux().Handle("GET", pattern, echo("x"))
+		}()
+	}
+
+	defer func() {
+		testing2.ExpectNotEqual(t, recover(), nil)
+	}()
+	mux := NewTreeMux()
+	mux.Handle("GET", "/users", echo("a"))
+	mux.Handle("GET", "/users", echo("b"))
+}
+
+func BenchmarkTreeMux(b *testing.B) {
+	mux := NewTreeMux()
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+	for i := 0; i < 100; i++ {
+		mux.Handle("GET", fmt.Sprintf("/api/v1/resource%d/:id", i), handler)
+	}
+	req := httptest.NewRequest("GET", "/api/v1/resource99/42", nil)
+	rw := httptest.NewRecorder()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mux.ServeHTTP(rw, req)
+	}
+}
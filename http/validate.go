@@ -0,0 +1,251 @@
+package http
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/wayn3h0/gop/i18n"
+	"github.com/wayn3h0/gop/uuid"
+)
+
+// FieldError represents an error of field in binding or validation.
+type FieldError struct {
+	Field   string `json:"field" xml:"field"`     // name of field in the source (e.g. query name), or the name of struct field
+	Rule    string `json:"rule" xml:"rule"`       // the failed rule, "type" for conversion errors
+	Message string `json:"message" xml:"message"` // human-readable message
+}
+
+// Error implements builtin.error interface.
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// FieldErrors represents the errors of fields.
+type FieldErrors []*FieldError
+
+// Error implements builtin.error interface.
+func (e FieldErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+
+	return "http: invalid fields: " + strings.Join(messages, "; ")
+}
+
+// rule represents a validation rule of field.
+type rule struct {
+	Name     string
+	Argument string
+}
+
+// parseRules parses the validate tag, the regexp rule must be the last one as it may contain commas.
+func parseRules(tag string) []rule {
+	var rules []rule
+	for len(tag) > 0 {
+		item := tag
+		if strings.HasPrefix(tag, "regexp=") {
+			tag = ""
+		} else if i := strings.IndexByte(tag, ','); i >= 0 {
+			item, tag = tag[:i], tag[i+1:]
+		} else {
+			tag = ""
+		}
+
+		name, argument := item, ""
+		if i := strings.IndexByte(item, '='); i >= 0 {
+			name, argument = item[:i], item[i+1:]
+		}
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			rules = append(rules, rule{Name: name, Argument: argument})
+		}
+	}
+
+	return rules
+}
+
+var regexps sync.Map // cache of compiled regular expressions
+
+// compile returns the compiled regular expression from cache.
+func compile(expr string) (*regexp.Regexp, error) {
+	if v, ok := regexps.Load(expr); ok {
+		return v.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	regexps.Store(expr, re)
+
+	return re, nil
+}
+
+// size returns the number for min and max rules: the value of number, or the length of string, slice and map.
+func size(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		return float64(len([]rune(v.String()))), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	default:
+		return 0, false
+	}
+}
+
+// check checks the value by rule, it returns the message if failed.
+func check(v reflect.Value, r rule) string {
+	if r.Name == "required" {
+		if v.IsZero() {
+			return "is required"
+		}
+		return ""
+	}
+
+	// the other rules skip the missing value (nil pointer)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	// the format rules skip the empty value, min, max and oneof check it as well
+	if v.IsZero() && r.Name != "min" && r.Name != "max" && r.Name != "oneof" {
+		return ""
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 && r.Name != "min" && r.Name != "max" {
+		for i := 0; i < v.Len(); i++ {
+			if message := check(v.Index(i), r); len(message) > 0 {
+				return message
+			}
+		}
+		return ""
+	}
+
+	str := fmt.Sprint(v.Interface())
+	switch r.Name {
+	case "min", "max":
+		limit, err := strconv.ParseFloat(r.Argument, 64)
+		if err != nil {
+			return "has invalid rule " + r.Name + "=" + r.Argument
+		}
+		n, ok := size(v)
+		if !ok {
+			return "does not support rule " + r.Name
+		}
+		if r.Name == "min" && n < limit {
+			return "must be at least " + r.Argument
+		}
+		if r.Name == "max" && n > limit {
+			return "must be at most " + r.Argument
+		}
+	case "regexp":
+		re, err := compile(r.Argument)
+		if err != nil {
+			return "has invalid rule regexp=" + r.Argument
+		}
+		if !re.MatchString(str) {
+			return "must match " + r.Argument
+		}
+	case "uuid":
+		if v.Type() != reflect.TypeOf(uuid.UUID{}) && !uuid.IsValid(str) {
+			return "must be a UUID"
+		}
+	case "country":
+		if _, ok := i18n.LookupCountry(str); !ok {
+			return "must be a country code"
+		}
+	case "currency":
+		if _, ok := i18n.LookupCurrency(str); !ok {
+			return "must be a currency code"
+		}
+	case "oneof":
+		for _, option := range strings.Fields(r.Argument) {
+			if str == option {
+				return ""
+			}
+		}
+		return "must be one of " + r.Argument
+	default:
+		return "has unknown rule " + r.Name
+	}
+
+	return ""
+}
+
+// validate validates the fields of struct by validate tags.
+func validate(v reflect.Value, errs *FieldErrors) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if len(f.PkgPath) > 0 && !f.Anonymous { // unexported
+			continue
+		}
+
+		fv := v.Field(i)
+		for _, r := range parseRules(f.Tag.Get("validate")) {
+			if message := check(fv, r); len(message) > 0 {
+				*errs = append(*errs, &FieldError{
+					Field:   fieldName(f),
+					Rule:    r.Name,
+					Message: message,
+				})
+				break
+			}
+		}
+
+		// nested struct
+		if fv.Kind() == reflect.Ptr && !fv.IsNil() {
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(uuid.UUID{}) && !isText(fv) {
+			validate(fv, errs)
+		}
+	}
+}
+
+// Validate validates the struct (or pointer to struct) by validate tags, it returns FieldErrors if any field is invalid.
+// The rules are separated by commas:
+//
+//	required      the value must not be zero
+//	min=n         the number must be at least n, or the length of string, slice and map
+//	max=n         the number must be at most n, or the length of string, slice and map
+//	oneof=a b c   the value must be one of the options separated by spaces
+//	uuid          the string must be a UUID
+//	country       the string must be a country code (alpha-2, alpha-3 or numeric) checked by i18n.LookupCountry
+//	currency      the string must be a currency code (alpha or numeric) checked by i18n.LookupCurrency
+//	regexp=expr   the string must match the regular expression, it must be the last rule
+//
+// The rules except required skip the nil pointer as missing, min, max and oneof check the zero value (e.g. 0 or ""),
+// so the optional field should be a pointer, the format rules (uuid, country, currency and regexp) skip the zero value.
+// The rules check each element of slice except min and max.
+func Validate(obj interface{}) error {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs FieldErrors
+	validate(v, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package en
+
+// messages of packages
+var mapOfMessageKeyToMessage = map[string]string{
+	"cron.every":          "every %s",
+	"cron.at":             "at %s",
+	"cron.everyDayAt":     "every day at %s",
+	"cron.everySecond":    "every second",
+	"cron.second":         "at second %s",
+	"cron.everyMinute":    "every minute",
+	"cron.minute":         "at minute %s",
+	"cron.hour":           "during hour %s",
+	"cron.day":            "on day %s of the month",
+	"cron.lastDay":        "on the last day of the month",
+	"cron.lastDayOffset":  "on %d days before the last day of the month",
+	"cron.lastWeekday":    "on the last weekday of the month",
+	"cron.nearestWeekday": "on the weekday nearest day %d of the month",
+	"cron.weekday":        "on %s",
+	"cron.lastOfMonth":    "on the last %s of the month",
+	"cron.nthOfMonth":     "on the %s %s of the month",
+	"cron.month":          "in %s",
+	"cron.year":           "in %s",
+	"cron.location":       "%s (%s)",
+	"cron.or":             " or ",
+	"cron.separator":      ", ",
+	"cron.list":           ", ",
+	"cron.through":        "-",
+	"cron.clockOrder":     "second minute hour",
+	"cron.order":          "clock days months years",
+	"cron.weekdayName0":   "Sunday",
+	"cron.weekdayName1":   "Monday",
+	"cron.weekdayName2":   "Tuesday",
+	"cron.weekdayName3":   "Wednesday",
+	"cron.weekdayName4":   "Thursday",
+	"cron.weekdayName5":   "Friday",
+	"cron.weekdayName6":   "Saturday",
+	"cron.monthName1":     "January",
+	"cron.monthName2":     "February",
+	"cron.monthName3":     "March",
+	"cron.monthName4":     "April",
+	"cron.monthName5":     "May",
+	"cron.monthName6":     "June",
+	"cron.monthName7":     "July",
+	"cron.monthName8":     "August",
+	"cron.monthName9":     "September",
+	"cron.monthName10":    "October",
+	"cron.monthName11":    "November",
+	"cron.monthName12":    "December",
+	"cron.nth1":           "1st",
+	"cron.nth2":           "2nd",
+	"cron.nth3":           "3rd",
+	"cron.nth4":           "4th",
+	"cron.nth5":           "5th",
+}
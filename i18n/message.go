@@ -0,0 +1,47 @@
+package i18n
+
+import (
+	"sort"
+	"sync"
+)
+
+var (
+	messagesMutex sync.RWMutex
+	messages      = make(map[string]String) // message key to multilingual message
+)
+
+// SetMessage sets the message of key with given language.
+// The language packs (e.g. github.com/wayn3h0/gop/i18n/en) set the messages of packages, the key is prefixed by the package name (e.g. "cron.every").
+func SetMessage(lang *Language, key string, message string) {
+	messagesMutex.Lock()
+	defer messagesMutex.Unlock()
+
+	s, ok := messages[key]
+	if !ok {
+		s = make(String)
+		messages[key] = s
+	}
+	s.Set(lang, message)
+}
+
+// LookupMessage returns the message of key with given language.
+func LookupMessage(lang *Language, key string) (string, bool) {
+	messagesMutex.RLock()
+	defer messagesMutex.RUnlock()
+
+	return messages[key].Get(lang)
+}
+
+// AllMessageKeys returns the sorted keys of all messages.
+func AllMessageKeys() []string {
+	messagesMutex.RLock()
+	defer messagesMutex.RUnlock()
+
+	keys := make([]string, 0, len(messages))
+	for key := range messages {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -23,4 +23,8 @@ func init() {
 			currency.Name.Set(lang, name)
 		}
 	}
+
+	for key, message := range mapOfMessageKeyToMessage {
+		i18n.SetMessage(lang, key, message)
+	}
 }
@@ -0,0 +1,54 @@
+package zh_hant
+
+// messages of packages
+var mapOfMessageKeyToMessage = map[string]string{
+	"cron.every":          "每隔 %s",
+	"cron.at":             "%s",
+	"cron.everyDayAt":     "每天 %s",
+	"cron.everySecond":    "每秒",
+	"cron.second":         "第%s秒",
+	"cron.everyMinute":    "每分鐘",
+	"cron.minute":         "第%s分",
+	"cron.hour":           "%s時",
+	"cron.day":            "每月%s日",
+	"cron.lastDay":        "每月最後一天",
+	"cron.lastDayOffset":  "每月最後一天前%d天",
+	"cron.lastWeekday":    "每月最後一個工作日",
+	"cron.nearestWeekday": "每月最接近%d日的工作日",
+	"cron.weekday":        "每%s",
+	"cron.lastOfMonth":    "每月最後一個%s",
+	"cron.nthOfMonth":     "每月第%s個%s",
+	"cron.month":          "%s",
+	"cron.year":           "%s年",
+	"cron.location":       "%s（%s）",
+	"cron.or":             "或",
+	"cron.separator":      " ",
+	"cron.list":           "、",
+	"cron.through":        "至",
+	"cron.clockOrder":     "hour minute second",
+	"cron.order":          "years months days clock",
+	"cron.weekdayName0":   "週日",
+	"cron.weekdayName1":   "週一",
+	"cron.weekdayName2":   "週二",
+	"cron.weekdayName3":   "週三",
+	"cron.weekdayName4":   "週四",
+	"cron.weekdayName5":   "週五",
+	"cron.weekdayName6":   "週六",
+	"cron.monthName1":     "1月",
+	"cron.monthName2":     "2月",
+	"cron.monthName3":     "3月",
+	"cron.monthName4":     "4月",
+	"cron.monthName5":     "5月",
+	"cron.monthName6":     "6月",
+	"cron.monthName7":     "7月",
+	"cron.monthName8":     "8月",
+	"cron.monthName9":     "9月",
+	"cron.monthName10":    "10月",
+	"cron.monthName11":    "11月",
+	"cron.monthName12":    "12月",
+	"cron.nth1":           "1",
+	"cron.nth2":           "2",
+	"cron.nth3":           "3",
+	"cron.nth4":           "4",
+	"cron.nth5":           "5",
+}
@@ -23,4 +23,10 @@ func init() {
 			currency.Name.Set(zh, name)
 		}
 	}
+
+	for _, key := range i18n.AllMessageKeys() {
+		if message, ok := i18n.LookupMessage(zh_hans, key); ok {
+			i18n.SetMessage(zh, key, message)
+		}
+	}
 }
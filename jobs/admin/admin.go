@@ -0,0 +1,223 @@
+package admin
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	http2 "github.com/wayn3h0/gop/http"
+	"github.com/wayn3h0/gop/jobs"
+)
+
+type step struct {
+	Name     string     `json:"name"`
+	Status   string     `json:"status"`
+	Started  *time.Time `json:"started,omitempty"`
+	Finished *time.Time `json:"finished,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+type execution struct {
+	Job        string     `json:"job"`
+	Activation *time.Time `json:"activation,omitempty"`
+	Started    *time.Time `json:"started,omitempty"`
+	Finished   *time.Time `json:"finished,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	Steps      []step     `json:"steps,omitempty"`
+}
+
+type job struct {
+	Name       string     `json:"name"`
+	Expression string     `json:"expression,omitempty"`
+	Pool       string     `json:"pool,omitempty"`
+	Priority   int        `json:"priority"`
+	Paused     bool       `json:"paused"`
+	Previous   *time.Time `json:"previous,omitempty"`
+	Next       *time.Time `json:"next,omitempty"`
+	At         *time.Time `json:"at,omitempty"`
+	Last       *execution `json:"last,omitempty"`
+}
+
+// timeOf returns nil for zero time for omitting it in JSON.
+func timeOf(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+// errorOf returns the message of error.
+func errorOf(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
+
+func newExecution(e *jobs.Execution) *execution {
+	v := &execution{
+		Job:        e.Job,
+		Activation: timeOf(e.Activation),
+		Started:    timeOf(e.Started),
+		Finished:   timeOf(e.Finished),
+		Error:      errorOf(e.Error),
+	}
+	for _, s := range e.Steps {
+		v.Steps = append(v.Steps, step{
+			Name:     s.Name,
+			Status:   s.Status.String(),
+			Started:  timeOf(s.Started),
+			Finished: timeOf(s.Finished),
+			Error:    errorOf(s.Error),
+		})
+	}
+
+	return v
+}
+
+func newJob(info *jobs.JobInfo) *job {
+	v := &job{
+		Name:       info.Name,
+		Expression: info.Expression,
+		Pool:       info.Pool,
+		Priority:   info.Priority,
+		Paused:     info.Paused,
+		Previous:   timeOf(info.Previous),
+		Next:       timeOf(info.Next),
+		At:         timeOf(info.At),
+	}
+	if info.Last != nil {
+		v.Last = newExecution(info.Last)
+	}
+
+	return v
+}
+
+type handler struct {
+	Scheduler *jobs.Scheduler
+	Prefix    string
+}
+
+// write writes the obj as JSON with status code.
+func (h *handler) write(rw http.ResponseWriter, status int, obj interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	http2.NewResponseWriter(rw).WriteJSON(obj)
+}
+
+// error writes the error message as JSON with status code.
+func (h *handler) error(rw http.ResponseWriter, status int, message string) {
+	h.write(rw, status, map[string]string{"error": message})
+}
+
+// find returns the job by name.
+func (h *handler) find(name string) *jobs.JobInfo {
+	for _, info := range h.Scheduler.Jobs() {
+		if len(name) > 0 && info.Name == name {
+			return &info
+		}
+	}
+
+	return nil
+}
+
+// history writes the executions of job (all jobs if name is empty).
+func (h *handler) history(rw http.ResponseWriter, name string) {
+	list := make([]*execution, 0)
+	for _, e := range h.Scheduler.History(name) {
+		list = append(list, newExecution(&e))
+	}
+
+	h.write(rw, http.StatusOK, list)
+}
+
+// ServeHTTP implements http.Handler interface.
+func (h *handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(req.URL.Path, h.Prefix), "/")
+	segments := strings.Split(path, "/")
+
+	switch {
+	case path == "jobs" && req.Method == http.MethodGet:
+		list := make([]*job, 0)
+		for _, info := range h.Scheduler.Jobs() {
+			list = append(list, newJob(&info))
+		}
+		h.write(rw, http.StatusOK, list)
+
+	case path == "history" && req.Method == http.MethodGet:
+		h.history(rw, "")
+
+	case len(segments) >= 2 && len(segments) <= 3 && segments[0] == "jobs":
+		name := segments[1]
+		info := h.find(name)
+		if info == nil {
+			h.error(rw, http.StatusNotFound, "job "+name+" is not found")
+			return
+		}
+
+		action := ""
+		if len(segments) == 3 {
+			action = segments[2]
+		}
+		h.serveJob(rw, req, info, action)
+
+	default:
+		h.error(rw, http.StatusNotFound, "resource is not found")
+	}
+}
+
+// serveJob serves the action of job.
+func (h *handler) serveJob(rw http.ResponseWriter, req *http.Request, info *jobs.JobInfo, action string) {
+	method := http.MethodPost
+	if action == "" || action == "history" {
+		method = http.MethodGet
+	}
+	if req.Method != method {
+		rw.Header().Set("Allow", method)
+		h.error(rw, http.StatusMethodNotAllowed, "method "+req.Method+" is not allowed")
+		return
+	}
+
+	switch action {
+	case "":
+		h.write(rw, http.StatusOK, newJob(info))
+	case "history":
+		h.history(rw, info.Name)
+	case "trigger":
+		h.Scheduler.Trigger(info.Name)
+		rw.WriteHeader(http.StatusAccepted)
+	case "pause":
+		h.Scheduler.Pause(info.Name)
+		rw.WriteHeader(http.StatusNoContent)
+	case "resume":
+		h.Scheduler.Resume(info.Name)
+		rw.WriteHeader(http.StatusNoContent)
+	default:
+		h.error(rw, http.StatusNotFound, "action "+action+" is not found")
+	}
+}
+
+// NewHandler returns a new HTTP handler for operating the scheduler, check the package document for the API.
+// The prefix is trimmed from the request path (e.g. "/admin").
+func NewHandler(scheduler *jobs.Scheduler, prefix string) http.Handler {
+	return &handler{
+		Scheduler: scheduler,
+		Prefix:    strings.TrimRight(prefix, "/"),
+	}
+}
+
+// New is short to NewHandler func.
+func New(scheduler *jobs.Scheduler, prefix string) http.Handler {
+	return NewHandler(scheduler, prefix)
+}
+
+// Mount registers the handler on the router under the prefix.
+// The router should support the catch-all pattern (e.g. "/admin/*path").
+func Mount(router *http2.Router, prefix string, scheduler *jobs.Scheduler, middlewares ...http2.Middleware) {
+	prefix = strings.TrimRight(prefix, "/")
+	h := NewHandler(scheduler, prefix)
+	router.Get(prefix+"/*path", h, middlewares...)
+	router.Post(prefix+"/*path", h, middlewares...)
+}
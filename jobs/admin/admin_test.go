@@ -0,0 +1,82 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	http2 "github.com/wayn3h0/gop/http"
+	"github.com/wayn3h0/gop/http/httprouter"
+	"github.com/wayn3h0/gop/jobs"
+	"github.com/wayn3h0/gop/jobs/expression/cron"
+	testing2 "github.com/wayn3h0/gop/testing"
+)
+
+func TestHandler(t *testing.T) {
+	clock := testing2.NewFakeClock(time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC))
+	s := jobs.NewScheduler(jobs.WithClock(clock))
+	expr, err := cron.NewExpression("0 2 * * *")
+	testing2.AssertEqual(t, err, nil)
+	runs := make(chan bool, 1)
+	s.Schedule(func() {
+		runs <- true
+	}, expr, jobs.WithName("backup"))
+	s.Start()
+	defer s.Stop()
+
+	router := http2.NewRouter(httprouter.New())
+	Mount(router, "/admin", s)
+	serve := func(method, path string, obj interface{}) int {
+		rw := httptest.NewRecorder()
+		router.ServeHTTP(rw, httptest.NewRequest(method, path, nil))
+		if obj != nil {
+			testing2.AssertEqual(t, json.Unmarshal(rw.Body.Bytes(), obj), nil)
+		}
+		return rw.Code
+	}
+
+	var list []map[string]interface{}
+	testing2.AssertEqual(t, serve("GET", "/admin/jobs", &list), http.StatusOK)
+	testing2.AssertEqual(t, len(list), 1)
+	testing2.ExpectEqual(t, list[0]["name"], "backup")
+	testing2.ExpectEqual(t, list[0]["expression"], "0 2 * * *")
+	testing2.ExpectEqual(t, list[0]["next"], "2018-10-01T02:00:00Z")
+
+	testing2.ExpectEqual(t, serve("GET", "/admin/jobs/unknown", nil), http.StatusNotFound)
+	testing2.ExpectEqual(t, serve("GET", "/admin/jobs/backup/trigger", nil), http.StatusMethodNotAllowed)
+
+	// trigger
+	testing2.ExpectEqual(t, serve("POST", "/admin/jobs/backup/trigger", nil), http.StatusAccepted)
+	select {
+	case <-runs:
+	case <-time.After(time.Second):
+		t.Fatal("job is not triggered")
+	}
+	time.Sleep(50 * time.Millisecond)
+	var history []map[string]interface{}
+	testing2.AssertEqual(t, serve("GET", "/admin/jobs/backup/history", &history), http.StatusOK)
+	testing2.AssertEqual(t, len(history), 1)
+	testing2.ExpectEqual(t, history[0]["job"], "backup")
+
+	// pause & resume
+	var info map[string]interface{}
+	testing2.ExpectEqual(t, serve("POST", "/admin/jobs/backup/pause", nil), http.StatusNoContent)
+	serve("GET", "/admin/jobs/backup", &info)
+	testing2.ExpectEqual(t, info["paused"], true)
+	testing2.ExpectEqual(t, info["last"].(map[string]interface{})["job"], "backup")
+
+	clock.BlockUntil(1)
+	clock.Advance(2 * time.Hour)
+	select {
+	case <-runs:
+		t.Fatal("paused job is activated")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	testing2.ExpectEqual(t, serve("POST", "/admin/jobs/backup/resume", nil), http.StatusNoContent)
+	serve("GET", "/admin/jobs/backup", &info)
+	testing2.ExpectEqual(t, info["paused"], false)
+	testing2.ExpectEqual(t, info["next"], "2018-10-02T02:00:00Z")
+}
@@ -0,0 +1,16 @@
+/*
+
+Package admin providers a HTTP handler for operating the jobs scheduler.
+
+The handler serves the JSON API below (relative to the prefix):
+
+	GET  /jobs                  lists the jobs with the last executions
+	GET  /jobs/{name}           returns the job
+	GET  /jobs/{name}/history   lists the recent executions of job
+	POST /jobs/{name}/trigger   runs the job immediately
+	POST /jobs/{name}/pause     pauses the job
+	POST /jobs/{name}/resume    resumes the job
+	GET  /history               lists the recent executions of all jobs
+
+*/
+package admin
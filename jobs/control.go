@@ -0,0 +1,130 @@
+package jobs
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// JobInfo represents a snapshot of job.
+type JobInfo struct {
+	Name       string
+	Expression string
+	Pool       string
+	Priority   int
+	Paused     bool
+	Previous   time.Time
+	Next       time.Time
+	At         time.Time  // activated time of one-off job
+	Last       *Execution // last execution in history, nil if not found
+}
+
+// find returns the job by name, the mutex should be held.
+func (s *Scheduler) find(name string) *job {
+	if len(name) == 0 {
+		return nil
+	}
+	for _, job := range s.jobs {
+		if job.Name == name {
+			return job
+		}
+	}
+
+	return nil
+}
+
+// Jobs returns the snapshots of jobs ordered by name.
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mutex.Lock()
+	list := make([]JobInfo, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		info := JobInfo{
+			Name:     job.Name,
+			Pool:     job.Pool,
+			Priority: job.Priority,
+			Paused:   job.Paused,
+			Previous: job.Previous,
+			Next:     job.Next,
+			At:       job.At,
+		}
+		if stringer, ok := job.Expression.(fmt.Stringer); ok {
+			info.Expression = stringer.String()
+		}
+		list = append(list, info)
+	}
+	s.mutex.Unlock()
+
+	for i := range list {
+		if len(list[i].Name) == 0 {
+			continue
+		}
+		if executions := s.history.list(list[i].Name); len(executions) > 0 {
+			list[i].Last = &executions[0]
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+// Trigger runs the job by name immediately without changing its schedule, it reports whether the job found.
+func (s *Scheduler) Trigger(name string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	job := s.find(name)
+	if job == nil {
+		return false
+	}
+
+	activation := s.clock.Now()
+	s.pool(job).submit(func() {
+		s.execute(job, activation)
+	}, job.Priority)
+
+	return true
+}
+
+// Pause pauses the job by name, the activations are skipped until it resumes.
+// It reports whether the job found, the paused one-off job is kept pending and runs when it resumes if it is overdue.
+func (s *Scheduler) Pause(name string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	job := s.find(name)
+	if job == nil {
+		return false
+	}
+	job.Paused = true
+
+	return true
+}
+
+// Resume resumes the paused job by name, it reports whether the job found.
+func (s *Scheduler) Resume(name string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	job := s.find(name)
+	if job == nil {
+		return false
+	}
+	job.Paused = false
+
+	// the overdue one-off job is due immediately
+	if s.running && !job.At.IsZero() && job.Next.IsZero() && job.Previous.Before(job.At) {
+		job.Next = job.At
+		for i := range s.jobs {
+			if s.jobs[i] == job {
+				heap.Fix(&s.jobs, i)
+				break
+			}
+		}
+		s.rearm(s.clock.Now())
+	}
+
+	return true
+}
@@ -0,0 +1,81 @@
+package expression
+
+import (
+	"time"
+)
+
+// Calendar represents a calendar excludes the times (e.g. holidays, blackout dates).
+type Calendar interface {
+	// Excludes reports whether the time is excluded.
+	Excludes(time.Time) bool
+}
+
+// CalendarFunc is an adapter to allow the use of ordinary functions as calendars.
+type CalendarFunc func(time.Time) bool
+
+// Excludes calls f(t).
+// Excludes implements Calendar interface.
+func (f CalendarFunc) Excludes(t time.Time) bool {
+	return f(t)
+}
+
+// Dates represents a calendar excludes the whole days of given dates.
+// The dates are compared in the location of given time.
+type Dates map[string]bool
+
+// dateLayout is the layout of key in Dates.
+const dateLayout = "2006-01-02"
+
+// Add adds the dates to calendar.
+func (d Dates) Add(dates ...time.Time) Dates {
+	for _, date := range dates {
+		d[date.Format(dateLayout)] = true
+	}
+
+	return d
+}
+
+// Excludes implements Calendar interface.
+func (d Dates) Excludes(t time.Time) bool {
+	return d[t.Format(dateLayout)]
+}
+
+// NewDates returns a new calendar excludes given dates.
+func NewDates(dates ...time.Time) Dates {
+	return make(Dates).Add(dates...)
+}
+
+// Weekends is a calendar excludes Saturday and Sunday.
+var Weekends Calendar = CalendarFunc(func(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+})
+
+// maxExclusions limits the activated times skipped by calendar.
+const maxExclusions = 1 << 16
+
+// exclusion represents an expression skips the times excluded by calendar.
+type exclusion struct {
+	Inner    Expression
+	Calendar Calendar
+}
+
+// Next implements Expression interface.
+func (e *exclusion) Next(from time.Time) time.Time {
+	next := from
+	for i := 0; i < maxExclusions; i++ {
+		next = e.Inner.Next(next)
+		if next.IsZero() || !e.Calendar.Excludes(next) {
+			return next
+		}
+	}
+
+	return time.Time{}
+}
+
+// Exclude returns an expression skips the activated times of inner expression excluded by calendar.
+func Exclude(inner Expression, calendar Calendar) Expression {
+	return &exclusion{
+		Inner:    inner,
+		Calendar: calendar,
+	}
+}
@@ -0,0 +1,70 @@
+package expression
+
+import (
+	"time"
+)
+
+// or represents an expression activates at the times any inner expression activates.
+type or []Expression
+
+// Next implements Expression interface.
+func (o or) Next(from time.Time) time.Time {
+	var next time.Time
+	for _, e := range o {
+		t := e.Next(from)
+		if !t.IsZero() && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+
+	return next
+}
+
+// Or returns an expression activates at the union of activated times of given expressions.
+func Or(exprs ...Expression) Expression {
+	return or(exprs)
+}
+
+// maxIntersections limits the iterations for finding the intersection.
+const maxIntersections = 1 << 16
+
+// and represents an expression activates at the times all inner expressions activate.
+type and []Expression
+
+// Next implements Expression interface.
+func (a and) Next(from time.Time) time.Time {
+	if len(a) == 0 {
+		return time.Time{}
+	}
+
+	for i := 0; i < maxIntersections; i++ {
+		var latest time.Time
+		matched := true
+		for j, e := range a {
+			t := e.Next(from)
+			if t.IsZero() {
+				return t
+			}
+			if j > 0 && !t.Equal(latest) {
+				matched = false
+			}
+			if t.After(latest) {
+				latest = t
+			}
+		}
+		if matched {
+			return latest
+		}
+
+		// all expressions activate at or after the latest one
+		from = latest.Add(-time.Nanosecond)
+	}
+
+	return time.Time{}
+}
+
+// And returns an expression activates at the intersection of activated times of given expressions.
+// e.g. And of "every day at 09:00" and "every Monday" activates at 09:00 on Mondays.
+func And(exprs ...Expression) Expression {
+	return and(exprs)
+}
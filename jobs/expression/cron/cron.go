@@ -1,20 +1,150 @@
 package cron
 
 import (
+	"strings"
+	"time"
+
 	"github.com/wayn3h0/gop/errors"
 	expr "github.com/wayn3h0/gop/jobs/expression"
-
-	"github.com/gorhill/cronexpr"
 )
 
 // Expression represents a cron expression.
-type expression struct {
-	*cronexpr.Expression
+// The schedule is evaluated on the wall clock of location (the location of given time if nil).
+type Expression struct {
+	text     string
+	name     string
+	hashed   bool
+	schedule *schedule
+	every    time.Duration
+	location *time.Location
+}
+
+// String returns the text of expression.
+func (e *Expression) String() string {
+	return e.text
+}
+
+// WithName returns the expression with the hashed values (H) for the job with given name, it returns e if e has no hashed values.
+// WithName implements expression.Named interface, the scheduler calls it for the named jobs.
+func (e *Expression) WithName(name string) expr.Expression {
+	if !e.hashed || name == e.name {
+		return e
+	}
+	named, err := Parse(e.text, name, e.location)
+	if err != nil { // never happens since the text was parsed
+		return e
+	}
+
+	return named
+}
+
+// Location returns the location of expression, nil means the location of given time.
+func (e *Expression) Location() *time.Location {
+	return e.location
+}
+
+// maxSkips limits the wall clock times skipped in a daylight saving time overlap.
+const maxSkips = 1 << 16
+
+// Next implements jobs.Expression interface.
+// The daylight saving time transitions are handled as below:
+// - Gap (the wall clock jumps forward): the time falls in the gap is shifted forward by the length of gap (e.g. 02:30 runs at 03:30).
+// - Overlap (the wall clock jumps backward): the time occurs twice runs once at the first occurrence.
+// The @every expression adds the duration to given time regardless of the wall clock.
+func (e *Expression) Next(from time.Time) time.Time {
+	if e.every > 0 {
+		return from.Add(e.every)
+	}
+
+	loc := e.location
+	if loc == nil {
+		loc = from.Location()
+	}
+
+	wall := toWall(from.In(loc))
+	for i := 0; i < maxSkips; i++ {
+		wall = e.schedule.next(wall)
+		if wall.IsZero() {
+			return time.Time{}
+		}
+
+		// the wall clock time may resolve to an instant not after given time in the overlap
+		if next := resolve(wall, loc); next.After(from) {
+			return next
+		}
+	}
+
+	return time.Time{}
+}
+
+// NextN returns the next n activated times from given time.
+// It's useful to preview the schedule, the list is shorter than n if the expression stops activating.
+func (e *Expression) NextN(from time.Time, n int) []time.Time {
+	var times []time.Time
+	for i := 0; i < n; i++ {
+		from = e.Next(from)
+		if from.IsZero() {
+			break
+		}
+		times = append(times, from)
+	}
+
+	return times
+}
+
+// toWall returns the wall clock time of t in UTC (without daylight saving time).
+func toWall(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// resolve returns the instant of wall clock time in location.
+// It returns the first occurrence for the time in overlap, and the shifted instant for the time in gap.
+func resolve(wall time.Time, loc *time.Location) time.Time {
+	_, before := wall.AddDate(0, 0, -1).In(loc).Zone()
+	_, after := wall.AddDate(0, 0, 1).In(loc).Zone()
+
+	var resolved time.Time
+	for _, offset := range []int{before, after} {
+		t := wall.Add(-time.Duration(offset) * time.Second).In(loc)
+		if toWall(t).Equal(wall) && (resolved.IsZero() || t.Before(resolved)) {
+			resolved = t
+		}
+	}
+	if resolved.IsZero() { // in gap
+		resolved = wall.Add(-time.Duration(before) * time.Second).In(loc)
+	}
+
+	return resolved
+}
+
+// parseLocation splits the CRON_TZ= (or TZ=) prefix from the expression and loads the location.
+func parseLocation(str string) (string, *time.Location, error) {
+	str = strings.TrimSpace(str)
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		if !strings.HasPrefix(str, prefix) {
+			continue
+		}
+
+		name := str[len(prefix):]
+		rest := ""
+		if i := strings.IndexAny(name, " \t"); i >= 0 {
+			name, rest = name[:i], strings.TrimSpace(name[i:])
+		}
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "cron: could not load time zone %q", name)
+		}
+
+		return rest, loc, nil
+	}
+
+	return str, nil, nil
 }
 
-func predefined(expr string) expr.Expression {
-	return &expression{
-		Expression: cronexpr.MustParse(expr),
+func predefined(text string) *Expression {
+	return &Expression{
+		text:     text,
+		schedule: macros[text],
 	}
 }
 
@@ -28,15 +158,55 @@ var (
 	HourlyExpression   = predefined("@hourly")   // every hour at the beginning of the hour
 )
 
-// NewExpression returns a new cron expression.
-// We use github.com/gorhill/cronexpr for parsing cron express inside.
-func NewExpression(expr string) (expr.Expression, error) {
-	cronexpr, err := cronexpr.Parse(expr)
+// Parse parses the cron expression, check the package document for the syntax.
+// The name of job is used for hashed values (H), and the expression is evaluated in given location.
+// The CRON_TZ= prefix takes precedence over the given location.
+func Parse(str string, name string, loc *time.Location) (*Expression, error) {
+	text, tz, err := parseLocation(str)
 	if err != nil {
-		return nil, errors.Wrapf(err, "cron: could not parse cron expression %q", expr)
+		return nil, err
 	}
+	if tz != nil {
+		loc = tz
+	}
+
+	e := &Expression{
+		text:     str,
+		name:     name,
+		location: loc,
+	}
+	if strings.HasPrefix(strings.ToLower(text), "@every") {
+		dur, err := time.ParseDuration(strings.TrimSpace(text[len("@every"):]))
+		if err != nil || dur < time.Second {
+			return nil, errors.Newf("cron: duration of expression %q is invalid, it should be at least 1 second", str)
+		}
+		e.every = dur
+
+		return e, nil
+	}
+
+	p := &parser{
+		Name: name,
+	}
+	e.schedule, err = p.parse(text)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cron: could not parse cron expression %q", str)
+	}
+	e.hashed = p.Hashed
+
+	return e, nil
+}
+
+// NewExpression returns a new cron expression, it has Describe and NextN for previewing the schedule.
+// The hashed values (H) are computed by the job name when the expression is scheduled with jobs.WithName.
+// The time zone can be specified by CRON_TZ= prefix (e.g. "CRON_TZ=Asia/Shanghai 0 9 * * *"),
+// otherwise the expression is evaluated in the location of given time.
+func NewExpression(str string) (*Expression, error) {
+	return NewExpressionInLocation(str, nil)
+}
 
-	return &expression{
-		Expression: cronexpr,
-	}, nil
+// NewExpressionInLocation is like as NewExpression but evaluates the expression in given location.
+// The CRON_TZ= prefix takes precedence over the given location.
+func NewExpressionInLocation(str string, loc *time.Location) (*Expression, error) {
+	return Parse(str, "", loc)
 }
@@ -0,0 +1,246 @@
+package cron
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	expr "github.com/wayn3h0/gop/jobs/expression"
+	testing2 "github.com/wayn3h0/gop/testing"
+)
+
+func TestExpressionLocation(t *testing.T) {
+	from := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"CRON_TZ=Asia/Shanghai 0 9 * * *":    time.Date(2018, 1, 1, 1, 0, 0, 0, time.UTC),
+		"CRON_TZ=America/New_York 0 9 * * *": time.Date(2018, 1, 1, 14, 0, 0, 0, time.UTC),
+		"TZ=UTC 0 9 * * *":                   time.Date(2018, 1, 1, 9, 0, 0, 0, time.UTC),
+	}
+	for str, want := range tests {
+		e, err := NewExpression(str)
+		testing2.AssertEqual(t, err, nil)
+		testing2.ExpectEqual(t, e.Next(from).UTC(), want)
+	}
+
+	shanghai, _ := time.LoadLocation("Asia/Shanghai")
+	e, _ := NewExpressionInLocation("0 9 * * *", shanghai)
+	testing2.ExpectEqual(t, e.Next(from).UTC(), time.Date(2018, 1, 1, 1, 0, 0, 0, time.UTC))
+
+	_, err := NewExpression("CRON_TZ=Mars/Olympus 0 9 * * *")
+	testing2.ExpectNotEqual(t, err, nil)
+}
+
+func TestExpressionDST(t *testing.T) {
+	// America/New_York: 2018-03-11 02:00 EST -> 03:00 EDT, 2018-11-04 02:00 EDT -> 01:00 EST
+	tests := []struct {
+		Expression string
+		From       time.Time
+		Want       []time.Time // in UTC
+	}{
+		{ // gap: shifted forward
+			"CRON_TZ=America/New_York 30 2 * * *",
+			time.Date(2018, 3, 10, 8, 0, 0, 0, time.UTC),
+			[]time.Time{
+				time.Date(2018, 3, 11, 7, 30, 0, 0, time.UTC), // 03:30 EDT
+				time.Date(2018, 3, 12, 6, 30, 0, 0, time.UTC), // 02:30 EDT
+			},
+		},
+		{ // gap: hourly
+			"CRON_TZ=America/New_York 30 * * * *",
+			time.Date(2018, 3, 11, 6, 0, 0, 0, time.UTC),
+			[]time.Time{
+				time.Date(2018, 3, 11, 6, 30, 0, 0, time.UTC), // 01:30 EST
+				time.Date(2018, 3, 11, 7, 30, 0, 0, time.UTC), // 03:30 EDT (shifted 02:30)
+				time.Date(2018, 3, 11, 8, 30, 0, 0, time.UTC), // 04:30 EDT
+			},
+		},
+		{ // overlap: once at first occurrence
+			"CRON_TZ=America/New_York 30 1 * * *",
+			time.Date(2018, 11, 4, 4, 0, 0, 0, time.UTC),
+			[]time.Time{
+				time.Date(2018, 11, 4, 5, 30, 0, 0, time.UTC), // 01:30 EDT
+				time.Date(2018, 11, 5, 6, 30, 0, 0, time.UTC), // 01:30 EST
+			},
+		},
+		{ // overlap: hourly
+			"CRON_TZ=America/New_York 30 * * * *",
+			time.Date(2018, 11, 4, 4, 0, 0, 0, time.UTC),
+			[]time.Time{
+				time.Date(2018, 11, 4, 4, 30, 0, 0, time.UTC), // 00:30 EDT
+				time.Date(2018, 11, 4, 5, 30, 0, 0, time.UTC), // 01:30 EDT
+				time.Date(2018, 11, 4, 7, 30, 0, 0, time.UTC), // 02:30 EST
+			},
+		},
+		{ // overlap: started in second occurrence
+			"CRON_TZ=America/New_York 45 1 * * *",
+			time.Date(2018, 11, 4, 6, 10, 0, 0, time.UTC), // 01:10 EST
+			[]time.Time{
+				time.Date(2018, 11, 5, 6, 45, 0, 0, time.UTC), // 01:45 EST
+			},
+		},
+	}
+	for i, test := range tests {
+		e, err := NewExpression(test.Expression)
+		testing2.AssertEqualL(t, err, nil, i)
+
+		next := test.From
+		for _, want := range test.Want {
+			next = e.Next(next)
+			testing2.ExpectEqualL(t, next.UTC(), want, i)
+		}
+	}
+}
+
+func TestExpressionNext(t *testing.T) {
+	from := time.Date(2018, 10, 1, 12, 0, 0, 0, time.UTC) // Monday
+	tests := map[string][]time.Time{
+		"*/20 9-10 * * *": {
+			time.Date(2018, 10, 2, 9, 0, 0, 0, time.UTC),
+			time.Date(2018, 10, 2, 9, 20, 0, 0, time.UTC),
+		},
+		"30 */20 * * * * *": { // 7 fields with second
+			time.Date(2018, 10, 1, 12, 0, 30, 0, time.UTC),
+			time.Date(2018, 10, 1, 12, 20, 30, 0, time.UTC),
+		},
+		"0 9 * * * 2020": { // 6 fields with year
+			time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC),
+			time.Date(2020, 1, 2, 9, 0, 0, 0, time.UTC),
+		},
+		"0 9 * * * *": { // 6 fields with any year
+			time.Date(2018, 10, 2, 9, 0, 0, 0, time.UTC),
+			time.Date(2018, 10, 3, 9, 0, 0, 0, time.UTC),
+		},
+		"5/1 0 * * *": { // step from value
+			time.Date(2018, 10, 2, 0, 5, 0, 0, time.UTC),
+			time.Date(2018, 10, 2, 0, 6, 0, 0, time.UTC),
+		},
+		"0 0 * * L": { // the last day of week
+			time.Date(2018, 10, 6, 0, 0, 0, 0, time.UTC),
+			time.Date(2018, 10, 13, 0, 0, 0, 0, time.UTC),
+		},
+		"0 0 0 1 1 * 2020-2021": { // 7 fields with year
+			time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		"0 0 L * *": {
+			time.Date(2018, 10, 31, 0, 0, 0, 0, time.UTC),
+			time.Date(2018, 11, 30, 0, 0, 0, 0, time.UTC),
+		},
+		"0 0 L-2 2 *": {
+			time.Date(2019, 2, 26, 0, 0, 0, 0, time.UTC),
+			time.Date(2020, 2, 27, 0, 0, 0, 0, time.UTC),
+		},
+		"0 0 LW * *": {
+			time.Date(2018, 10, 31, 0, 0, 0, 0, time.UTC), // Wednesday
+			time.Date(2018, 11, 30, 0, 0, 0, 0, time.UTC), // Friday
+			time.Date(2018, 12, 31, 0, 0, 0, 0, time.UTC), // Monday
+			time.Date(2019, 1, 31, 0, 0, 0, 0, time.UTC),  // Thursday
+			time.Date(2019, 2, 28, 0, 0, 0, 0, time.UTC),  // Thursday
+			time.Date(2019, 3, 29, 0, 0, 0, 0, time.UTC),  // Friday (31 is Sunday)
+		},
+		"0 0 1W,15W * *": {
+			time.Date(2018, 10, 15, 0, 0, 0, 0, time.UTC), // Monday
+			time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC),  // Thursday
+			time.Date(2018, 11, 15, 0, 0, 0, 0, time.UTC), // Thursday
+			time.Date(2018, 12, 3, 0, 0, 0, 0, time.UTC),  // Monday (1 is Saturday)
+			time.Date(2018, 12, 14, 0, 0, 0, 0, time.UTC), // Friday (15 is Saturday)
+		},
+		"0 0 ? * 5L": {
+			time.Date(2018, 10, 26, 0, 0, 0, 0, time.UTC),
+			time.Date(2018, 11, 30, 0, 0, 0, 0, time.UTC),
+		},
+		"0 0 * * FRI#3": {
+			time.Date(2018, 10, 19, 0, 0, 0, 0, time.UTC),
+			time.Date(2018, 11, 16, 0, 0, 0, 0, time.UTC),
+		},
+		"0 0 13 * 5": { // day-of-month or day-of-week
+			time.Date(2018, 10, 5, 0, 0, 0, 0, time.UTC),
+			time.Date(2018, 10, 12, 0, 0, 0, 0, time.UTC),
+			time.Date(2018, 10, 13, 0, 0, 0, 0, time.UTC),
+		},
+		"0 0 * JAN-FEB SUN,7": {
+			time.Date(2019, 1, 6, 0, 0, 0, 0, time.UTC),
+			time.Date(2019, 1, 13, 0, 0, 0, 0, time.UTC),
+		},
+		"@weekly": {
+			time.Date(2018, 10, 7, 0, 0, 0, 0, time.UTC),
+		},
+		"@every 90m": {
+			time.Date(2018, 10, 1, 13, 30, 0, 0, time.UTC),
+			time.Date(2018, 10, 1, 15, 0, 0, 0, time.UTC),
+		},
+		"0 0 30 2 *": nil, // never
+	}
+	for str, want := range tests {
+		e, err := Parse(str, "", nil)
+		testing2.AssertEqual(t, err, nil)
+		testing2.ExpectEqual(t, e.NextN(from, len(want)+1)[:len(want)], want)
+	}
+}
+
+func TestExpressionHash(t *testing.T) {
+	e1, _ := Parse("H H(0-5) * * *", "backup", nil)
+	e2, _ := Parse("H H(0-5) * * *", "backup", nil)
+	testing2.ExpectEqual(t, e1.NextN(time.Now(), 3), e2.NextN(time.Now(), 3))
+
+	from := time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC)
+	spread := make(map[time.Time]bool)
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		e, err := Parse("H H(0-5) * * *", name, nil)
+		testing2.AssertEqual(t, err, nil)
+		next := e.Next(from)
+		if next.Hour() > 5 {
+			t.Errorf("hashed hour %d is out of range", next.Hour())
+		}
+		spread[next] = true
+	}
+	testing2.ExpectNotEqual(t, len(spread), 1)
+
+	e, _ := Parse("H/15 * * * *", "report", nil)
+	times := e.NextN(from, 4)
+	for i := 1; i < len(times); i++ {
+		testing2.ExpectEqualL(t, times[i].Sub(times[i-1]), 15*time.Minute, i)
+	}
+
+	// the day of month exists in every month
+	for i := 0; i < 100; i++ {
+		e, err := Parse("0 0 H * *", strconv.Itoa(i), nil)
+		testing2.AssertEqualL(t, err, nil, i)
+		testing2.ExpectEqualL(t, e.Next(from).Day() <= 28, true, i)
+	}
+
+	// the name is given by the scheduler
+	unnamed, err := NewExpression("H H(0-5) * * *")
+	testing2.AssertEqual(t, err, nil)
+	for i, name := range []string{"a", "b", "c"} {
+		named := unnamed.WithName(name)
+		e, _ := Parse("H H(0-5) * * *", name, nil)
+		testing2.ExpectEqualL(t, named.Next(from), e.Next(from), i)
+	}
+	plain, _ := NewExpression("0 0 * * *")
+	testing2.ExpectEqual(t, plain.WithName("a"), expr.Expression(plain))
+	var _ expr.Named = plain
+}
+
+func TestParseError(t *testing.T) {
+	for i, str := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"* * * * MON#6",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"0 0 0 * * * 1969",
+		"0 0 9 * * * 1969",
+		"0 9 * * * H", // hashed year
+		"@every 1ms",
+		"@every tomorrow",
+	} {
+		_, err := Parse(str, "", nil)
+		testing2.ExpectNotEqualL(t, err, nil, i)
+	}
+}
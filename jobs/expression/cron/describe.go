@@ -0,0 +1,232 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wayn3h0/gop/i18n"
+	_ "github.com/wayn3h0/gop/i18n/en" // the default language
+)
+
+// describer represents a describer renders the expression in a language.
+type describer struct {
+	Language *i18n.Language
+}
+
+// phrase returns the formatted message of key in the language.
+func (d *describer) phrase(key string, args ...interface{}) string {
+	format, _ := i18n.LookupMessage(d.Language, "cron."+key)
+	if len(args) == 0 {
+		return format
+	}
+
+	return fmt.Sprintf(format, args...)
+}
+
+// ordered concatenates the non-empty parts in the order of message (e.g. "hour minute second") with separator.
+func (d *describer) ordered(key string, parts map[string]string) string {
+	var list []string
+	for _, name := range strings.Fields(d.phrase(key)) {
+		list = append(list, parts[name])
+	}
+
+	return join(d.phrase("separator"), list...)
+}
+
+// values returns the list of values in bits, the consecutive values (at least 3) are rendered as range.
+func (d *describer) values(bits uint64, min, max int, name func(int) string) string {
+	var items []string
+	for v := min; v <= max; v++ {
+		if bits&(1<<uint(v)) == 0 {
+			continue
+		}
+
+		end := v
+		for end < max && bits&(1<<uint(end+1)) != 0 {
+			end++
+		}
+		switch {
+		case end-v >= 2:
+			items = append(items, name(v)+d.phrase("through")+name(end))
+		case end-v == 1:
+			items = append(items, name(v), name(end))
+		default:
+			items = append(items, name(v))
+		}
+		v = end
+	}
+
+	return strings.Join(items, d.phrase("list"))
+}
+
+// single returns the value if the bits has only one value.
+func single(bits uint64, min, max int) (int, bool) {
+	value := -1
+	for v := min; v <= max; v++ {
+		if bits&(1<<uint(v)) != 0 {
+			if value >= 0 {
+				return 0, false
+			}
+			value = v
+		}
+	}
+
+	return value, value >= 0
+}
+
+// clock returns the time part of description.
+func (d *describer) clock(s *schedule) (string, bool) {
+	second, ok1 := single(s.Second, 0, 59)
+	minute, ok2 := single(s.Minute, 0, 59)
+	hour, ok3 := single(s.Hour, 0, 23)
+	if ok1 && ok2 && ok3 {
+		if second == 0 {
+			return fmt.Sprintf("%02d:%02d", hour, minute), true
+		}
+		return fmt.Sprintf("%02d:%02d:%02d", hour, minute, second), true
+	}
+
+	var seconds, minutes, hours string
+	switch {
+	case s.Second == bits(0, 59, 1):
+		seconds = d.phrase("everySecond")
+	case s.Second != bits(0, 0, 1):
+		seconds = d.phrase("second", d.values(s.Second, 0, 59, strconv.Itoa))
+	}
+	switch {
+	case s.Minute != bits(0, 59, 1):
+		minutes = d.phrase("minute", d.values(s.Minute, 0, 59, strconv.Itoa))
+	case s.Second != bits(0, 59, 1):
+		minutes = d.phrase("everyMinute")
+	}
+	if s.Hour != bits(0, 23, 1) {
+		hours = d.phrase("hour", d.values(s.Hour, 0, 23, strconv.Itoa))
+	}
+
+	return d.ordered("clockOrder", map[string]string{"second": seconds, "minute": minutes, "hour": hours}), false
+}
+
+// join concatenates the non-empty parts with separator.
+func join(separator string, parts ...string) string {
+	var list []string
+	for _, part := range parts {
+		if len(part) > 0 {
+			list = append(list, part)
+		}
+	}
+
+	return strings.Join(list, separator)
+}
+
+// weekday returns the name of weekday.
+func (d *describer) weekday(v int) string {
+	return d.phrase("weekdayName" + strconv.Itoa(v))
+}
+
+// month returns the name of month.
+func (d *describer) month(v int) string {
+	return d.phrase("monthName" + strconv.Itoa(v))
+}
+
+// days returns the day part of description.
+func (d *describer) days(s *schedule) string {
+	var doms, dows []string
+	if !s.AnyDay {
+		if s.Day != 0 {
+			doms = append(doms, d.phrase("day", d.values(s.Day, 1, 31, strconv.Itoa)))
+		}
+		for _, offset := range s.LastDays {
+			if offset == 0 {
+				doms = append(doms, d.phrase("lastDay"))
+			} else {
+				doms = append(doms, d.phrase("lastDayOffset", offset))
+			}
+		}
+		if s.LastWeekday {
+			doms = append(doms, d.phrase("lastWeekday"))
+		}
+		for _, day := range s.NearestWeekdays {
+			doms = append(doms, d.phrase("nearestWeekday", day))
+		}
+	}
+	if !s.AnyWeekday {
+		if s.Weekday != 0 {
+			dows = append(dows, d.phrase("weekday", d.values(s.Weekday, 0, 6, d.weekday)))
+		}
+		for _, w := range s.Weekdays {
+			if w.Nth == 0 {
+				dows = append(dows, d.phrase("lastOfMonth", d.weekday(int(w.Weekday))))
+			} else {
+				dows = append(dows, d.phrase("nthOfMonth", d.phrase("nth"+strconv.Itoa(w.Nth)), d.weekday(int(w.Weekday))))
+			}
+		}
+	}
+
+	return strings.Join(append(doms, dows...), d.phrase("or"))
+}
+
+// describe returns the description of expression.
+func (d *describer) describe(e *Expression) string {
+	if e.every > 0 {
+		return d.phrase("every", e.every.String())
+	}
+
+	s := e.schedule
+	clock, fixed := d.clock(s)
+	days := d.days(s)
+	var months, years string
+	if s.Month != bits(1, 12, 1) {
+		months = d.phrase("month", d.values(s.Month, 1, 12, d.month))
+	}
+	if s.Years != nil {
+		var list []string
+		for year := minYear; year <= maxYear; year++ {
+			if !s.Years[year] {
+				continue
+			}
+			end := year
+			for s.Years[end+1] {
+				end++
+			}
+			if end > year {
+				list = append(list, strconv.Itoa(year)+"-"+strconv.Itoa(end))
+			} else {
+				list = append(list, strconv.Itoa(year))
+			}
+			year = end
+		}
+		years = d.phrase("year", strings.Join(list, d.phrase("list")))
+	}
+
+	if fixed {
+		if len(days) == 0 && len(months) == 0 && len(years) == 0 {
+			clock = d.phrase("everyDayAt", clock)
+		} else {
+			clock = d.phrase("at", clock)
+		}
+	}
+
+	return d.ordered("order", map[string]string{"clock": clock, "days": days, "months": months, "years": years})
+}
+
+// Describe returns the human-readable description of expression in given language.
+// The messages are provided by the language packs of i18n (e.g. importing github.com/wayn3h0/gop/i18n/zh-hans),
+// it falls back to English if the pack of language is not imported.
+func (e *Expression) Describe(lang *i18n.Language) string {
+	if lang == nil {
+		lang, _ = i18n.LookupLanguage("en")
+	} else if _, ok := i18n.LookupMessage(lang, "cron.every"); !ok {
+		lang, _ = i18n.LookupLanguage("en")
+	}
+
+	d := &describer{
+		Language: lang,
+	}
+	text := d.describe(e)
+	if e.location != nil {
+		text = d.phrase("location", text, e.location.String())
+	}
+
+	return text
+}
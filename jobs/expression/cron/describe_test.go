@@ -0,0 +1,46 @@
+package cron
+
+import (
+	"testing"
+
+	"github.com/wayn3h0/gop/i18n"
+	_ "github.com/wayn3h0/gop/i18n/zh"
+	_ "github.com/wayn3h0/gop/i18n/zh-hant"
+	testing2 "github.com/wayn3h0/gop/testing"
+)
+
+func TestDescribe(t *testing.T) {
+	en, _ := i18n.LookupLanguage("en")
+	zh, _ := i18n.LookupLanguage("zh")
+	hant, _ := i18n.LookupLanguage("zh-hant")
+	fr, _ := i18n.LookupLanguage("fr")
+	tests := []struct {
+		Expression string
+		Language   *i18n.Language
+		Want       string
+	}{
+		{"0 9 * * *", en, "every day at 09:00"},
+		{"0 9 * * *", zh, "每天 09:00"},
+		{"30 15 9 * * 1-5 *", en, "at 09:15:30, on Monday-Friday"},
+		{"30 15 9 * * 1-5 *", zh, "每周一至周五 09:15:30"},
+		{"*/30 9-17 * * *", en, "at minute 0, 30, during hour 9-17"},
+		{"*/30 9-17 * * *", zh, "9至17时 第0、30分"},
+		{"* * * * *", en, "every minute"},
+		{"* * * * * * *", zh, "每秒"},
+		{"0 9 * * *", fr, "every day at 09:00"}, // no language pack
+		{"0 0 L * *", en, "at 00:00, on the last day of the month"},
+		{"0 0 LW * *", hant, "每月最後一個工作日 00:00"},
+		{"0 0 15W 1,6 *", en, "at 00:00, on the weekday nearest day 15 of the month, in January, June"},
+		{"0 0 * * 5#3", en, "at 00:00, on the 3rd Friday of the month"},
+		{"0 0 * * 5L", zh, "每月最后一个周五 00:00"},
+		{"0 0 0 1 1 * 2020-2022", zh, "2020-2022年 1月 每月1日 00:00"},
+		{"CRON_TZ=Asia/Shanghai 0 9 * * *", en, "every day at 09:00 (Asia/Shanghai)"},
+		{"@every 1h30m", en, "every 1h30m0s"},
+		{"@daily", nil, "every day at 00:00"},
+	}
+	for i, test := range tests {
+		e, err := Parse(test.Expression, "", nil)
+		testing2.AssertEqualL(t, err, nil, i)
+		testing2.ExpectEqualL(t, e.Describe(test.Language), test.Want, i)
+	}
+}
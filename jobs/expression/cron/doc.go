@@ -0,0 +1,51 @@
+/*
+
+Package cron providers a cron expression.
+
+The expression has 5, 6 or 7 fields separated by white spaces:
+
+	Field          Required   Values            Special Characters
+	-----          --------   ------            ------------------
+	Second         No         0-59              * / , - H
+	Minute         Yes        0-59              * / , - H
+	Hour           Yes        0-23              * / , - H
+	Day of month   Yes        1-31              * / , - H ? L W
+	Month          Yes        1-12 or JAN-DEC   * / , - H
+	Day of week    Yes        0-7 or SUN-SAT    * / , - H ? L #
+	Year           No         1970-2099         * / , -
+
+	5 fields: minute hour day-of-month month day-of-week
+	6 fields: minute hour day-of-month month day-of-week year
+	7 fields: second minute hour day-of-month month day-of-week year
+
+The 6-field expression ends with year as the former parser (cronexpr), so the seconds are only supported by 7 fields
+(e.g. "30 0 9 * * * *" is at 09:00:30 every day).
+
+The special characters:
+
+	*       all values of the field (? is same as * in day-of-month and day-of-week).
+	a-b     the values in range.
+	a/n     every n values from a (a-b/n is allowed, and an asterisk with step means the whole range).
+	L       the last day of month (L-n for n days before the last day, LW for the last weekday).
+	nW      the weekday (Monday-Friday) nearest day n in the same month.
+	nL      the last weekday n of month in day-of-week (e.g. 5L is the last Friday), L alone is Saturday (the last day of week).
+	n#k     the k-th weekday n of month in day-of-week (e.g. 5#3 is the third Friday).
+	H       a hashed value by the name of job, it spreads the jobs with same expression (H(a-b) and H/n are allowed).
+	        The name is given by Parse or by the scheduler for the job named by jobs.WithName,
+	        and H in day-of-month is in 1-28 so the job runs in every month.
+
+If both day-of-month and day-of-week are restricted, the expression activates when either field matches.
+
+The predefined schedules:
+
+	@yearly (or @annually)   0 0 1 1 *
+	@monthly                 0 0 1 * *
+	@weekly                  0 0 * * 0
+	@daily (or @midnight)    0 0 * * *
+	@hourly                  0 * * * *
+	@every <duration>        every duration from the previous activation (e.g. @every 1h30m)
+
+The time zone can be specified by CRON_TZ= prefix (e.g. "CRON_TZ=Asia/Shanghai 0 9 * * *").
+
+*/
+package cron
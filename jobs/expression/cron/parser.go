@@ -0,0 +1,303 @@
+package cron
+
+import (
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wayn3h0/gop/errors"
+)
+
+// bounds represents the allowed values of field.
+type bounds struct {
+	Name  string
+	Min   int
+	Max   int
+	Names map[string]int
+}
+
+var (
+	secondBounds  = bounds{"second", 0, 59, nil}
+	minuteBounds  = bounds{"minute", 0, 59, nil}
+	hourBounds    = bounds{"hour", 0, 23, nil}
+	dayBounds     = bounds{"day-of-month", 1, 31, nil}
+	monthBounds   = bounds{"month", 1, 12, map[string]int{"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6, "JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12}}
+	weekdayBounds = bounds{"day-of-week", 0, 7, map[string]int{"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6}}
+	yearBounds    = bounds{"year", minYear, maxYear, nil}
+)
+
+// parser represents a parser of cron expression fields.
+type parser struct {
+	Name   string // name of job for hashed values
+	Hashed bool   // whether any hashed value is parsed
+}
+
+// hash returns the hashed value in [0, n) for given field.
+func (p *parser) hash(field string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(p.Name + "\x00" + field))
+	return int(h.Sum32() % uint32(n))
+}
+
+// value parses a number or a name.
+func (p *parser) value(str string, b bounds) (int, error) {
+	if v, ok := b.Names[strings.ToUpper(str)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(str)
+	if err != nil || v < b.Min || v > b.Max {
+		return 0, errors.Newf("cron: value %q of %s field is invalid, it should be in %d-%d", str, b.Name, b.Min, b.Max)
+	}
+
+	return v, nil
+}
+
+// rangeOf parses the range (a, a-b, * or H with optional step) to values.
+func (p *parser) rangeOf(str string, b bounds) ([]int, error) {
+	step, stepped := 1, false
+	if i := strings.Index(str, "/"); i >= 0 {
+		n, err := strconv.Atoi(str[i+1:])
+		if err != nil || n <= 0 {
+			return nil, errors.Newf("cron: step %q of %s field is invalid", str[i+1:], b.Name)
+		}
+		step, stepped = n, true
+		str = str[:i]
+	}
+
+	min, max := b.Min, b.Max
+	if b.Name == weekdayBounds.Name {
+		max = 6 // 7 is only allowed as single value
+	}
+	hashed := false
+	switch {
+	case str == "*" || str == "?":
+	case strings.HasPrefix(str, "H"):
+		hashed = true
+		p.Hashed = true
+		if b.Name == dayBounds.Name {
+			max = 28 // the days exist in every month
+		}
+		if rest := str[1:]; len(rest) > 0 {
+			if !strings.HasPrefix(rest, "(") || !strings.HasSuffix(rest, ")") {
+				return nil, errors.Newf("cron: hashed value %q of %s field is invalid", str, b.Name)
+			}
+			values, err := p.rangeOf(rest[1:len(rest)-1], b)
+			if err != nil || len(values) == 0 {
+				return nil, errors.Newf("cron: range of hashed value %q of %s field is invalid", str, b.Name)
+			}
+			min, max = values[0], values[len(values)-1]
+		}
+	default:
+		parts := strings.SplitN(str, "-", 2)
+		v, err := p.value(parts[0], b)
+		if err != nil {
+			return nil, err
+		}
+		min = v
+		if len(parts) == 2 {
+			max, err = p.value(parts[1], b)
+			if err != nil {
+				return nil, err
+			}
+			if max < min {
+				return nil, errors.Newf("cron: range %q of %s field is invalid", str, b.Name)
+			}
+		} else if !stepped {
+			max = v
+		}
+	}
+
+	if hashed {
+		size := max - min + 1
+		if step > 1 { // hashed offset of step
+			if step < size {
+				size = step
+			}
+			min += p.hash(b.Name, size)
+		} else { // single hashed value
+			min += p.hash(b.Name, size)
+			max = min
+		}
+	}
+
+	var values []int
+	for v := min; v <= max; v += step {
+		if b.Name == weekdayBounds.Name && v == 7 { // Sunday
+			values = append(values, 0)
+			continue
+		}
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+// bits parses the field to bits.
+func (p *parser) bits(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		values, err := p.rangeOf(item, b)
+		if err != nil {
+			return 0, err
+		}
+		for _, v := range values {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+// day parses the day-of-month field.
+func (p *parser) day(field string, s *schedule) error {
+	if field == "*" || field == "?" {
+		s.Day = bits(1, 31, 1)
+		s.AnyDay = true
+		return nil
+	}
+
+	for _, item := range strings.Split(field, ",") {
+		upper := strings.ToUpper(item)
+		switch {
+		case upper == "L":
+			s.LastDays = append(s.LastDays, 0)
+		case upper == "LW":
+			s.LastWeekday = true
+		case strings.HasPrefix(upper, "L-"):
+			n, err := strconv.Atoi(upper[2:])
+			if err != nil || n < 0 || n > 30 {
+				return errors.Newf("cron: offset %q of day-of-month field is invalid", item)
+			}
+			s.LastDays = append(s.LastDays, n)
+		case strings.HasSuffix(upper, "W"):
+			v, err := p.value(upper[:len(upper)-1], dayBounds)
+			if err != nil {
+				return err
+			}
+			s.NearestWeekdays = append(s.NearestWeekdays, v)
+		default:
+			values, err := p.rangeOf(item, dayBounds)
+			if err != nil {
+				return err
+			}
+			for _, v := range values {
+				s.Day |= 1 << uint(v)
+			}
+		}
+	}
+
+	return nil
+}
+
+// weekday parses the day-of-week field.
+func (p *parser) weekday(field string, s *schedule) error {
+	if field == "*" || field == "?" {
+		s.Weekday = bits(0, 6, 1)
+		s.AnyWeekday = true
+		return nil
+	}
+
+	for _, item := range strings.Split(field, ",") {
+		upper := strings.ToUpper(item)
+		switch {
+		case upper == "L": // the last day of week
+			s.Weekday |= 1 << uint(time.Saturday)
+		case strings.HasSuffix(upper, "L"):
+			v, err := p.value(upper[:len(upper)-1], weekdayBounds)
+			if err != nil {
+				return err
+			}
+			s.Weekdays = append(s.Weekdays, weekday{Weekday: time.Weekday(v % 7)})
+		case strings.Contains(upper, "#"):
+			parts := strings.SplitN(upper, "#", 2)
+			v, err := p.value(parts[0], weekdayBounds)
+			if err != nil {
+				return err
+			}
+			n, err := strconv.Atoi(parts[1])
+			if err != nil || n < 1 || n > 5 {
+				return errors.Newf("cron: nth %q of day-of-week field is invalid, it should be in 1-5", item)
+			}
+			s.Weekdays = append(s.Weekdays, weekday{Weekday: time.Weekday(v % 7), Nth: n})
+		default:
+			values, err := p.rangeOf(item, weekdayBounds)
+			if err != nil {
+				return err
+			}
+			for _, v := range values {
+				s.Weekday |= 1 << uint(v)
+			}
+		}
+	}
+
+	return nil
+}
+
+// years parses the year field.
+func (p *parser) years(field string) (map[int]bool, error) {
+	if field == "*" {
+		return nil, nil
+	}
+
+	years := make(map[int]bool)
+	for _, item := range strings.Split(field, ",") {
+		if strings.HasPrefix(item, "H") {
+			return nil, errors.New("cron: hashed value is not allowed in year field")
+		}
+		values, err := p.rangeOf(item, yearBounds)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			years[v] = true
+		}
+	}
+
+	return years, nil
+}
+
+// parse parses the fields of cron expression.
+func (p *parser) parse(str string) (*schedule, error) {
+	if s, ok := macros[strings.ToLower(str)]; ok {
+		return s, nil
+	}
+
+	fields := strings.Fields(str)
+	switch len(fields) {
+	case 5:
+		fields = append(append([]string{"0"}, fields...), "*")
+	case 6: // with year
+		fields = append([]string{"0"}, fields...)
+	case 7: // with second and year
+	default:
+		return nil, errors.Newf("cron: number of fields %d is invalid, it should be 5, 6 or 7", len(fields))
+	}
+
+	s := new(schedule)
+	var err error
+	if s.Second, err = p.bits(fields[0], secondBounds); err != nil {
+		return nil, err
+	}
+	if s.Minute, err = p.bits(fields[1], minuteBounds); err != nil {
+		return nil, err
+	}
+	if s.Hour, err = p.bits(fields[2], hourBounds); err != nil {
+		return nil, err
+	}
+	if err = p.day(fields[3], s); err != nil {
+		return nil, err
+	}
+	if s.Month, err = p.bits(fields[4], monthBounds); err != nil {
+		return nil, err
+	}
+	if err = p.weekday(fields[5], s); err != nil {
+		return nil, err
+	}
+	if s.Years, err = p.years(fields[6]); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
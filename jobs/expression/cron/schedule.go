@@ -0,0 +1,203 @@
+package cron
+
+import (
+	"time"
+)
+
+// Years supported by the year field.
+const (
+	minYear = 1970
+	maxYear = 2099
+)
+
+// weekday represents a weekday of month (e.g. the third Friday).
+type weekday struct {
+	Weekday time.Weekday
+	Nth     int // 1-5, or 0 for the last one
+}
+
+// schedule represents the parsed fields of cron expression.
+// The values of fields are stored as bits (the n-th bit for value n).
+type schedule struct {
+	Second  uint64
+	Minute  uint64
+	Hour    uint64
+	Day     uint64
+	Month   uint64
+	Weekday uint64
+	Years   map[int]bool // nil for every year
+
+	AnyDay          bool // day-of-month is * or ?
+	AnyWeekday      bool // day-of-week is * or ?
+	LastDays        []int
+	LastWeekday     bool
+	NearestWeekdays []int
+	Weekdays        []weekday
+}
+
+// lastDay returns the last day of month.
+func lastDay(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// nearestWeekday returns the weekday (Monday-Friday) nearest the day in same month.
+func nearestWeekday(year int, month time.Month, day int) int {
+	last := lastDay(year, month)
+	if day > last {
+		return 0
+	}
+
+	switch time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday() {
+	case time.Saturday:
+		if day == 1 {
+			return day + 2
+		}
+		return day - 1
+	case time.Sunday:
+		if day == last {
+			return day - 2
+		}
+		return day + 1
+	default:
+		return day
+	}
+}
+
+// matchDayOfMonth reports whether the day matches day-of-month field.
+func (s *schedule) matchDayOfMonth(t time.Time) bool {
+	year, month, day := t.Date()
+	if s.Day&(1<<uint(day)) != 0 {
+		return true
+	}
+
+	last := lastDay(year, month)
+	for _, offset := range s.LastDays {
+		if last-offset == day {
+			return true
+		}
+	}
+	if s.LastWeekday && nearestWeekday(year, month, last) == day {
+		return true
+	}
+	for _, d := range s.NearestWeekdays {
+		if nearestWeekday(year, month, d) == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchDayOfWeek reports whether the day matches day-of-week field.
+func (s *schedule) matchDayOfWeek(t time.Time) bool {
+	if s.Weekday&(1<<uint(t.Weekday())) != 0 {
+		return true
+	}
+
+	for _, w := range s.Weekdays {
+		if w.Weekday != t.Weekday() {
+			continue
+		}
+		if w.Nth == 0 && t.Day()+7 > lastDay(t.Year(), t.Month()) {
+			return true
+		}
+		if w.Nth > 0 && (t.Day()-1)/7+1 == w.Nth {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchDay reports whether the day matches day-of-month and day-of-week fields.
+func (s *schedule) matchDay(t time.Time) bool {
+	switch {
+	case s.AnyDay && s.AnyWeekday:
+		return true
+	case s.AnyDay:
+		return s.matchDayOfWeek(t)
+	case s.AnyWeekday:
+		return s.matchDayOfMonth(t)
+	default:
+		return s.matchDayOfMonth(t) || s.matchDayOfWeek(t)
+	}
+}
+
+// next returns the closest activated time after given wall clock time (in UTC).
+// It returns zero time if there is no activation before the max year.
+func (s *schedule) next(t time.Time) time.Time {
+	t = t.Truncate(time.Second).Add(time.Second)
+	for t.Year() <= maxYear {
+		year, month, day := t.Date()
+		switch {
+		case s.Years != nil && !s.Years[year]:
+			t = time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+		case s.Month&(1<<uint(month)) == 0:
+			t = time.Date(year, month+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.matchDay(t):
+			t = time.Date(year, month, day+1, 0, 0, 0, 0, time.UTC)
+		case s.Hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(year, month, day, t.Hour()+1, 0, 0, 0, time.UTC)
+		case s.Minute&(1<<uint(t.Minute())) == 0:
+			t = time.Date(year, month, day, t.Hour(), t.Minute()+1, 0, 0, time.UTC)
+		case s.Second&(1<<uint(t.Second())) == 0:
+			t = t.Add(time.Second)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// bits returns the bits of values in [min, max] with step.
+func bits(min, max, step int) uint64 {
+	var b uint64
+	for v := min; v <= max; v += step {
+		b |= 1 << uint(v)
+	}
+
+	return b
+}
+
+// macro returns the schedule activates at 00:00:00 of every day (or the beginning of every hour if hour is negative).
+// The day, month and weekday are restricted to the value if it's not negative.
+func macro(hour, day, month, weekday int) *schedule {
+	s := &schedule{
+		Second:     bits(0, 0, 1),
+		Minute:     bits(0, 0, 1),
+		Hour:       bits(0, 23, 1),
+		Day:        bits(1, 31, 1),
+		Month:      bits(1, 12, 1),
+		Weekday:    bits(0, 6, 1),
+		AnyDay:     true,
+		AnyWeekday: true,
+	}
+	if hour >= 0 {
+		s.Hour = bits(hour, hour, 1)
+	}
+	if day >= 0 {
+		s.Day = bits(day, day, 1)
+		s.AnyDay = false
+	}
+	if month >= 0 {
+		s.Month = bits(month, month, 1)
+	}
+	if weekday >= 0 {
+		s.Weekday = bits(weekday, weekday, 1)
+		s.AnyWeekday = false
+	}
+
+	return s
+}
+
+// macros represents the predefined schedules.
+var macros = map[string]*schedule{
+	"@yearly":   macro(0, 1, 1, -1),
+	"@annually": macro(0, 1, 1, -1),
+	"@monthly":  macro(0, 1, -1, -1),
+	"@weekly":   macro(0, -1, -1, 0),
+	"@daily":    macro(0, -1, -1, -1),
+	"@midnight": macro(0, -1, -1, -1),
+	"@hourly":   macro(-1, -1, -1, -1),
+}
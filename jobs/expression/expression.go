@@ -9,3 +9,12 @@ type Expression interface {
 	// Next returns the closest activated time from given time.
 	Next(time.Time) time.Time
 }
+
+// Named represents an expression depends on the name of job (e.g. the hashed values of cron expression).
+// The scheduler calls WithName for the job named by jobs.WithName.
+type Named interface {
+	Expression
+
+	// WithName returns the expression for the job with given name.
+	WithName(name string) Expression
+}
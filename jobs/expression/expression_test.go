@@ -0,0 +1,109 @@
+package expression
+
+import (
+	"testing"
+	"time"
+
+	testing2 "github.com/wayn3h0/gop/testing"
+)
+
+// every represents an expression activates at the multiples of duration.
+type every time.Duration
+
+func (e every) Next(from time.Time) time.Time {
+	return from.Truncate(time.Duration(e)).Add(time.Duration(e))
+}
+
+// after represents an expression activates after the duration from given time.
+type after time.Duration
+
+func (a after) Next(from time.Time) time.Time {
+	return from.Add(time.Duration(a))
+}
+
+var base = time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC) // Monday
+
+// times returns the first n activated times from base.
+func times(e Expression, n int) []time.Time {
+	var list []time.Time
+	next := base
+	for i := 0; i < n; i++ {
+		next = e.Next(next)
+		list = append(list, next)
+	}
+
+	return list
+}
+
+func TestJitter(t *testing.T) {
+	e := Jitter(every(time.Hour), time.Minute)
+	for i, next := range times(e, 100) {
+		want := base.Add(time.Duration(i+1) * time.Hour)
+		if next.Before(want) || !next.Before(want.Add(time.Minute)) {
+			t.Fatalf("%d: %s is out of jitter range", i, next)
+		}
+	}
+
+	// the delays don't accumulate
+	e = Jitter(after(time.Hour), time.Minute)
+	for i, next := range times(e, 100) {
+		want := base.Add(time.Duration(i+1) * time.Hour)
+		if next.Before(want) || !next.Before(want.Add(time.Minute)) {
+			t.Fatalf("%d: %s drifts", i, next)
+		}
+	}
+
+	// the delays are derived from the name and the activation
+	a := e.(Named).WithName("job")
+	b := Jitter(after(time.Hour), time.Minute).(Named).WithName("job")
+	testing2.ExpectEqual(t, times(a, 10), times(b, 10))
+	testing2.ExpectEqual(t, a.Next(base), a.Next(base))
+}
+
+func TestWindow(t *testing.T) {
+	e := Window(every(time.Hour), base.Add(3*time.Hour), base.Add(5*time.Hour))
+	testing2.ExpectEqual(t, times(e, 3), []time.Time{base.Add(3 * time.Hour), base.Add(4 * time.Hour), {}})
+}
+
+func TestLimit(t *testing.T) {
+	e := Limit(every(time.Hour), base, 2)
+	testing2.ExpectEqual(t, times(e, 3), []time.Time{base.Add(time.Hour), base.Add(2 * time.Hour), {}})
+
+	// repeated and earlier calls do not consume the activations
+	e = Limit(every(time.Hour), base, 3)
+	for i := 0; i < 3; i++ {
+		testing2.ExpectEqualL(t, e.Next(base), base.Add(time.Hour), i)
+	}
+	testing2.ExpectEqual(t, e.Next(base.Add(90*time.Minute)), base.Add(2*time.Hour))
+	testing2.ExpectEqual(t, e.Next(base.Add(-time.Hour)), base.Add(time.Hour))
+	testing2.ExpectEqual(t, times(e, 4), []time.Time{base.Add(time.Hour), base.Add(2 * time.Hour), base.Add(3 * time.Hour), {}})
+
+	// anchored at the given time rather than the first call
+	e = Limit(after(time.Hour), base, 2)
+	testing2.ExpectEqual(t, e.Next(base.Add(90*time.Minute)), time.Time{}) // base+2h30m is out of the limit
+	testing2.ExpectEqual(t, times(e, 3), []time.Time{base.Add(time.Hour), base.Add(2 * time.Hour), {}})
+	testing2.ExpectEqual(t, Limit(every(time.Hour), base, 0).Next(base), time.Time{})
+}
+
+func TestExclude(t *testing.T) {
+	day := every(24 * time.Hour)
+	holidays := NewDates(base.AddDate(0, 0, 2)) // Wednesday
+	e := Exclude(Exclude(day, holidays), Weekends)
+	testing2.ExpectEqual(t, times(e, 3), []time.Time{base.AddDate(0, 0, 1), base.AddDate(0, 0, 3), base.AddDate(0, 0, 4)})
+}
+
+func TestComposite(t *testing.T) {
+	e := Or(every(2*time.Hour), every(3*time.Hour))
+	testing2.ExpectEqual(t, times(e, 4), []time.Time{base.Add(2 * time.Hour), base.Add(3 * time.Hour), base.Add(4 * time.Hour), base.Add(6 * time.Hour)})
+
+	e = And(every(2*time.Hour), every(3*time.Hour))
+	testing2.ExpectEqual(t, times(e, 2), []time.Time{base.Add(6 * time.Hour), base.Add(12 * time.Hour)})
+
+	e = Limit(And(every(time.Hour), every(2*time.Hour)), base, 1)
+	testing2.ExpectEqual(t, times(e, 2), []time.Time{base.Add(2 * time.Hour), {}})
+}
+
+func TestOnce(t *testing.T) {
+	e := Once(base.Add(time.Hour))
+	testing2.ExpectEqual(t, times(e, 2), []time.Time{base.Add(time.Hour), {}})
+}
@@ -0,0 +1,92 @@
+package expression
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxJitterBases limits the remembered activations of jitter.
+const maxJitterBases = 1024
+
+// jitter represents an expression delays each activation by a pseudo-random duration derived from the activation.
+type jitter struct {
+	Inner   Expression
+	Maximum time.Duration
+	seed    string              // the name of job, or a random seed if not named
+	bases   map[int64]time.Time // the jittered activations to the activations of inner expression
+	locker  sync.Mutex
+}
+
+// delay returns the delay of the activation of inner expression, it's the same for the same name and activation.
+func (j *jitter) delay(base time.Time) time.Duration {
+	h := fnv.New64a()
+	h.Write([]byte(j.seed))
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(base.UnixNano()))
+	h.Write(b[:])
+
+	return time.Duration(h.Sum64() % uint64(j.Maximum))
+}
+
+// Next implements Expression interface.
+// The next activation is computed from the un-jittered one if from is a jittered activation, so the delays don't accumulate.
+func (j *jitter) Next(from time.Time) time.Time {
+	j.locker.Lock()
+	defer j.locker.Unlock()
+
+	base, ok := j.bases[from.UnixNano()]
+	if !ok {
+		base = from
+	}
+	for i := 0; i < maxIntersections; i++ {
+		base = j.Inner.Next(base)
+		if base.IsZero() {
+			return base
+		}
+		next := base.Add(j.delay(base))
+		if next.After(from) {
+			if len(j.bases) >= maxJitterBases {
+				j.bases = make(map[int64]time.Time)
+			}
+			j.bases[next.UnixNano()] = base
+			return next
+		}
+	}
+
+	return time.Time{}
+}
+
+// WithName implements Named interface.
+// The delays are derived from the name, so the job has the same delays across the restarts and instances.
+func (j *jitter) WithName(name string) Expression {
+	inner := j.Inner
+	if named, ok := inner.(Named); ok {
+		inner = named.WithName(name)
+	}
+
+	return &jitter{
+		Inner:   inner,
+		Maximum: j.Maximum,
+		seed:    name,
+		bases:   make(map[int64]time.Time),
+	}
+}
+
+// Jitter returns an expression delays each activation of inner expression by a pseudo-random duration in [0, max).
+// It's useful to avoid thundering herds, the max should be less than the interval of inner expression.
+// The delay is derived from the activation of inner expression and the name of job (random if not named), the next activation is always computed from the un-jittered one.
+func Jitter(inner Expression, max time.Duration) Expression {
+	if max <= 0 {
+		return inner
+	}
+
+	return &jitter{
+		Inner:   inner,
+		Maximum: max,
+		seed:    strconv.FormatInt(time.Now().UnixNano(), 36),
+		bases:   make(map[int64]time.Time),
+	}
+}
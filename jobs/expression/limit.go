@@ -0,0 +1,73 @@
+package expression
+
+import (
+	"time"
+)
+
+// limit represents an expression activates limited times.
+type limit struct {
+	Inner   Expression
+	Maximum int
+	From    time.Time // the anchor of activations
+	start   time.Time // first activated time
+	end     time.Time // last activated time
+}
+
+// bound computes the first and the last activated times from the anchor.
+func (l *limit) bound() {
+	if l.Maximum <= 0 {
+		return
+	}
+
+	l.start = l.Inner.Next(l.From)
+	l.end = l.start
+	for i := 1; i < l.Maximum && !l.end.IsZero(); i++ {
+		next := l.Inner.Next(l.end)
+		if next.IsZero() {
+			break
+		}
+		l.end = next
+	}
+}
+
+// Next implements Expression interface.
+func (l *limit) Next(from time.Time) time.Time {
+	if l.start.IsZero() {
+		return time.Time{}
+	}
+
+	next := l.Inner.Next(from)
+	if next.IsZero() || next.After(l.end) {
+		return time.Time{}
+	}
+	if next.Before(l.start) {
+		return l.start
+	}
+
+	return next
+}
+
+// WithName implements Named interface.
+func (l *limit) WithName(name string) Expression {
+	named, ok := l.Inner.(Named)
+	if !ok {
+		return l
+	}
+
+	return Limit(named.WithName(name), l.From, l.Maximum)
+}
+
+// Limit returns an expression activates at the first max activated times of inner expression after from (e.g. the time the job is scheduled).
+// The activations are fixed when it's created, so the Next is idempotent and can be called repeatedly and composed with other expressions,
+// but the activations skipped by the scheduler (e.g. missed by a late timer) are counted as well,
+// use jobs.WithMaxRuns to limit the number of runs of job instead.
+func Limit(inner Expression, from time.Time, max int) Expression {
+	l := &limit{
+		Inner:   inner,
+		Maximum: max,
+		From:    from,
+	}
+	l.bound()
+
+	return l
+}
@@ -0,0 +1,26 @@
+package expression
+
+import (
+	"time"
+)
+
+// once represents an expression activates only once.
+type once struct {
+	At time.Time
+}
+
+// Next implements Expression interface.
+func (e *once) Next(from time.Time) time.Time {
+	if from.Before(e.At) {
+		return e.At
+	}
+
+	return time.Time{}
+}
+
+// Once returns an expression activates only once at given time.
+func Once(at time.Time) Expression {
+	return &once{
+		At: at,
+	}
+}
@@ -0,0 +1,48 @@
+package expression
+
+import (
+	"time"
+)
+
+// window represents an expression activates in a time window only.
+type window struct {
+	Inner Expression
+	Start time.Time
+	End   time.Time
+}
+
+// Next implements Expression interface.
+func (w *window) Next(from time.Time) time.Time {
+	if !w.Start.IsZero() && from.Before(w.Start) {
+		from = w.Start.Add(-time.Nanosecond) // includes the start
+	}
+
+	next := w.Inner.Next(from)
+	if !w.End.IsZero() && !next.Before(w.End) {
+		return time.Time{}
+	}
+
+	return next
+}
+
+// Window returns an expression activates at the times of inner expression in [start, end).
+// The zero start or end means unbounded.
+func Window(inner Expression, start, end time.Time) Expression {
+	return &window{
+		Inner: inner,
+		Start: start,
+		End:   end,
+	}
+}
+
+// StartAfter returns an expression activates at the times of inner expression from start.
+// This is short for Window(inner, start, time.Time{}).
+func StartAfter(inner Expression, start time.Time) Expression {
+	return Window(inner, start, time.Time{})
+}
+
+// EndBefore returns an expression activates at the times of inner expression before end.
+// This is short for Window(inner, time.Time{}, end).
+func EndBefore(inner Expression, end time.Time) Expression {
+	return Window(inner, time.Time{}, end)
+}
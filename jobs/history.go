@@ -0,0 +1,80 @@
+package jobs
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultHistorySize is the default number of executions kept in history.
+const DefaultHistorySize = 100
+
+// Execution represents an execution of job.
+type Execution struct {
+	Job        string
+	Activation time.Time
+	Started    time.Time
+	Finished   time.Time
+	Error      error           // first error of workflow steps, or the panic of plain job
+	Steps      []StepExecution // steps of workflow, nil for plain job
+}
+
+// history represents a bounded history of executions, the oldest executions are discarded.
+type history struct {
+	mutex      sync.Mutex
+	size       int
+	executions []*Execution
+}
+
+// add adds the execution to history.
+func (h *history) add(e *Execution) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.size <= 0 {
+		return
+	}
+	if len(h.executions) >= h.size {
+		copy(h.executions, h.executions[1:])
+		h.executions = h.executions[:len(h.executions)-1]
+	}
+	h.executions = append(h.executions, e)
+}
+
+// list returns the executions of job (all jobs if name is empty), the newest first.
+func (h *history) list(name string) []Execution {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	var list []Execution
+	for i := len(h.executions) - 1; i >= 0; i-- {
+		if e := h.executions[i]; len(name) == 0 || e.Job == name {
+			list = append(list, *e)
+		}
+	}
+
+	return list
+}
+
+// execute runs the job for given activation and records the execution in history.
+func (s *Scheduler) execute(job *job, activation time.Time) {
+	s.lock(job, activation, func() {
+		e := &Execution{
+			Job:        job.Name,
+			Activation: activation,
+			Started:    s.clock.Now(),
+		}
+		if job.Workflow != nil {
+			e.Steps, e.Error = job.Workflow.run(s.clock)
+		} else {
+			e.Error = job.call()
+		}
+		e.Finished = s.clock.Now()
+
+		s.history.add(e)
+	})
+}
+
+// History returns the recent executions of job (all jobs if name is empty), the newest first.
+func (s *Scheduler) History(name string) []Execution {
+	return s.history.list(name)
+}
@@ -0,0 +1,58 @@
+package jobs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wayn3h0/gop/jobs/expression/cycle"
+	testing2 "github.com/wayn3h0/gop/testing"
+)
+
+func TestHistory(t *testing.T) {
+	h := &history{size: 3}
+	for _, name := range []string{"a", "b", "a", "b", "a"} {
+		h.add(&Execution{Job: name})
+	}
+
+	testing2.ExpectEqual(t, len(h.list("")), 3)
+	testing2.ExpectEqual(t, len(h.list("a")), 2)
+	testing2.ExpectEqual(t, h.list("b"), []Execution{{Job: "b"}})
+
+	h = &history{}
+	h.add(&Execution{Job: "a"})
+	testing2.ExpectEqual(t, len(h.list("")), 0)
+}
+
+func TestSchedulerHistoryPanic(t *testing.T) {
+	clock := testing2.NewFakeClock(time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC))
+	s := NewScheduler(WithClock(clock))
+
+	done := make(chan time.Time, 1)
+	s.Schedule(func() {
+		defer func() {
+			done <- clock.Now()
+		}()
+		panic("boom")
+	}, cycle.NewExpression(time.Hour), WithName("crash"))
+	s.Start()
+	defer s.Stop()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+	_, ok := receive(done, time.Second)
+	testing2.AssertEqual(t, ok, true)
+	time.Sleep(50 * time.Millisecond)
+
+	history := s.History("crash")
+	testing2.AssertEqual(t, len(history), 1)
+	testing2.ExpectEqual(t, history[0].Finished, clock.Now())
+	testing2.AssertNotEqual(t, history[0].Error, nil)
+	testing2.ExpectEqual(t, strings.Contains(history[0].Error.Error(), `jobs: job "crash" panicked: boom`), true)
+
+	// the scheduler keeps running
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+	_, ok = receive(done, time.Second)
+	testing2.ExpectEqual(t, ok, true)
+}
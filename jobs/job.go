@@ -1,35 +1,60 @@
 package jobs
 
 import (
-	"sort"
 	"time"
 
+	"github.com/wayn3h0/gop/errors"
 	"github.com/wayn3h0/gop/jobs/expression"
 )
 
 // job represents a background job.
 type job struct {
-	Function   func()
-	Expression expression.Expression
-	Name       string
-	Previous   time.Time
-	Next       time.Time
+	Function      func()
+	Expression    expression.Expression
+	Name          string
+	MisfirePolicy MisfirePolicy
+	Priority      int
+	Pool          string
+	Handler       string
+	At            time.Time // activated time of one-off job
+	Workflow      *Workflow
+	Paused        bool
+	MaxRuns       int // maximum number of runs, zero means unlimited
+	Runs          int // number of runs since the scheduler started
+	Previous      time.Time
+	Next          time.Time
+	index         int // index in the heap
 }
 
-// jobs represents a sortable collection of job.
+// call calls the function of job, it converts the panic to error.
+func (j *job) call() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Newf("jobs: job %q panicked: %v", j.Name, r)
+		}
+	}()
+
+	j.Function()
+
+	return nil
+}
+
+// jobs represents a min-heap of job ordered by the next activated time, the jobs never activate are at the bottom.
 type jobs []*job
 
-// Len implements sort.Interface.
+// Len implements heap.Interface.
 func (j jobs) Len() int {
 	return len(j)
 }
 
-// Swap implements sort.Interface.
+// Swap implements heap.Interface.
 func (j jobs) Swap(x, y int) {
 	j[x], j[y] = j[y], j[x]
+	j[x].index = x
+	j[y].index = y
 }
 
-// Less implements sort.Interface.
+// Less implements heap.Interface.
 func (j jobs) Less(x, y int) bool {
 	if j[x].Next.IsZero() {
 		return false
@@ -42,7 +67,21 @@ func (j jobs) Less(x, y int) bool {
 	return j[x].Next.Before(j[y].Next)
 }
 
-// Sort sorts the jobs.
-func (j jobs) Sort() {
-	sort.Sort(j)
+// Push implements heap.Interface.
+func (j *jobs) Push(x interface{}) {
+	job := x.(*job)
+	job.index = len(*j)
+	*j = append(*j, job)
+}
+
+// Pop implements heap.Interface.
+func (j *jobs) Pop() interface{} {
+	old := *j
+	n := len(old)
+	job := old[n-1]
+	old[n-1] = nil
+	job.index = -1
+	*j = old[:n-1]
+
+	return job
 }
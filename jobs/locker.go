@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"time"
+)
+
+// Locker represents a distributed lock ensures each activation of a job runs on exactly one node.
+// The lock is held by a lease, the lease expires if it is not renewed (e.g. the node crashed).
+// Only the named jobs are locked, the nodes sharing a locker should have roughly synchronized clocks.
+type Locker interface {
+	// Lock tries to acquire the lease of job for given activation, it reports whether the lease acquired.
+	// It fails if the activation (or a later one) has been acquired, or the lease of previous activation has not been released or expired.
+	Lock(name string, activation time.Time, lease time.Duration) (bool, error)
+
+	// Renew extends the lease of job for given activation, it reports whether the lease is still held.
+	Renew(name string, activation time.Time, lease time.Duration) (bool, error)
+
+	// Unlock releases the lease of job for given activation.
+	Unlock(name string, activation time.Time) error
+}
+
+// DefaultLease is the default lease duration of locker.
+const DefaultLease = time.Minute
+
+// lock runs the function while holding the lease of job for given activation.
+// The lease will be renewed periodically until the function returns.
+// It returns immediately if the lease cannot be acquired.
+func (s *Scheduler) lock(job *job, activation time.Time, fn func()) {
+	if s.locker == nil || len(job.Name) == 0 {
+		fn()
+		return
+	}
+
+	acquired, err := s.locker.Lock(job.Name, activation, s.lease)
+	if err != nil {
+		s.logger.Errorf("jobs: could not acquire lease of job %q: %s", job.Name, err)
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	done := make(chan bool)
+	go func() {
+		timer := s.clock.NewTimer(s.lease / 3)
+		defer timer.Stop()
+		for {
+			select {
+			case <-timer.C():
+				timer.Reset(s.lease / 3)
+				held, err := s.locker.Renew(job.Name, activation, s.lease)
+				if err != nil {
+					s.logger.Errorf("jobs: could not renew lease of job %q: %s", job.Name, err)
+				} else if !held {
+					s.logger.Warnf("jobs: lease of job %q has been lost", job.Name)
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	defer func() {
+		close(done)
+		err := s.locker.Unlock(job.Name, activation)
+		if err != nil {
+			s.logger.Errorf("jobs: could not release lease of job %q: %s", job.Name, err)
+		}
+	}()
+
+	fn()
+}
@@ -0,0 +1,6 @@
+/*
+
+Package memory providers an in-memory job locker, it's useful for testing and the schedulers in a single process.
+
+*/
+package memory
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/wayn3h0/gop/jobs"
+)
+
+type lease struct {
+	Activation time.Time
+	Expires    time.Time
+}
+
+type locker struct {
+	Locker sync.Mutex
+	Leases map[string]*lease
+}
+
+func (l *locker) Lock(name string, activation time.Time, duration time.Duration) (bool, error) {
+	l.Locker.Lock()
+	defer l.Locker.Unlock()
+
+	now := time.Now()
+	if v, ok := l.Leases[name]; ok {
+		if !v.Activation.Before(activation) || v.Expires.After(now) {
+			return false, nil
+		}
+	}
+
+	l.Leases[name] = &lease{
+		Activation: activation,
+		Expires:    now.Add(duration),
+	}
+
+	return true, nil
+}
+
+func (l *locker) Renew(name string, activation time.Time, duration time.Duration) (bool, error) {
+	l.Locker.Lock()
+	defer l.Locker.Unlock()
+
+	now := time.Now()
+	if v, ok := l.Leases[name]; ok && v.Activation.Equal(activation) && v.Expires.After(now) {
+		v.Expires = now.Add(duration)
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (l *locker) Unlock(name string, activation time.Time) error {
+	l.Locker.Lock()
+	defer l.Locker.Unlock()
+
+	if v, ok := l.Leases[name]; ok && v.Activation.Equal(activation) {
+		v.Expires = time.Time{} // keeps the activation for rejecting the late nodes
+	}
+
+	return nil
+}
+
+// NewLocker returns a new in-memory job locker.
+func NewLocker() jobs.Locker {
+	return &locker{
+		Leases: make(map[string]*lease),
+	}
+}
+
+// New is short to NewLocker func.
+func New() jobs.Locker {
+	return NewLocker()
+}
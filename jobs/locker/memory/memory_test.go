@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wayn3h0/gop/jobs"
+	testing2 "github.com/wayn3h0/gop/testing"
+)
+
+func TestLocker(t *testing.T) {
+	l := NewLocker()
+	t1 := time.Now().Truncate(time.Hour)
+	t2 := t1.Add(time.Hour)
+
+	acquired, _ := l.Lock("job", t1, time.Minute)
+	testing2.ExpectEqual(t, acquired, true)
+	acquired, _ = l.Lock("job", t1, time.Minute) // same activation
+	testing2.ExpectEqual(t, acquired, false)
+	acquired, _ = l.Lock("job", t2, time.Minute) // previous lease is held
+	testing2.ExpectEqual(t, acquired, false)
+
+	held, _ := l.Renew("job", t1, time.Minute)
+	testing2.ExpectEqual(t, held, true)
+
+	l.Unlock("job", t1)
+	acquired, _ = l.Lock("job", t1, time.Minute) // released but same activation
+	testing2.ExpectEqual(t, acquired, false)
+	acquired, _ = l.Lock("job", t2, time.Millisecond)
+	testing2.ExpectEqual(t, acquired, true)
+
+	// crashed node (lease expired)
+	time.Sleep(5 * time.Millisecond)
+	held, _ = l.Renew("job", t2, time.Minute)
+	testing2.ExpectEqual(t, held, false)
+	acquired, _ = l.Lock("job", t2.Add(time.Hour), time.Minute)
+	testing2.ExpectEqual(t, acquired, true)
+}
+
+// expression activates at every 50 milliseconds.
+type expression struct{}
+
+func (expression) Next(from time.Time) time.Time {
+	return from.Truncate(50 * time.Millisecond).Add(50 * time.Millisecond)
+}
+
+func TestSchedulers(t *testing.T) {
+	l := NewLocker()
+	var mutex sync.Mutex
+	runs := make(map[time.Time]int)
+	for i := 0; i < 3; i++ { // nodes
+		s := jobs.NewScheduler(jobs.WithLocker(l, time.Second))
+		s.Schedule(func() {
+			mutex.Lock()
+			runs[time.Now().Round(50*time.Millisecond)]++
+			mutex.Unlock()
+		}, expression{}, jobs.WithName("job"))
+		s.Start()
+		defer s.Stop()
+	}
+	time.Sleep(275 * time.Millisecond)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	testing2.ExpectNotEqual(t, len(runs), 0)
+	for at, n := range runs {
+		if n != 1 {
+			t.Errorf("activation at %s ran %d times", at, n)
+		}
+	}
+}
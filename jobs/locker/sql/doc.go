@@ -0,0 +1,6 @@
+/*
+
+Package sql providers a job locker holding the leases in a SQL database table.
+
+*/
+package sql
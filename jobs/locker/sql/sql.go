@@ -0,0 +1,133 @@
+package sql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wayn3h0/gop/errors"
+	"github.com/wayn3h0/gop/jobs"
+	sql2 "github.com/wayn3h0/gop/sql"
+	"github.com/wayn3h0/gop/uuid"
+)
+
+// DefaultTable is the default name of table for storing job leases.
+const DefaultTable = "job_leases"
+
+type locker struct {
+	Database    sql2.Database
+	Table       string
+	Placeholder sql2.Placeholder
+	Owner       string // identifies the node holding the lease
+}
+
+// statement returns the statement with table name and bind variables in style of database.
+func (l *locker) statement(format string) string {
+	return l.Placeholder.Rebind(fmt.Sprintf(format, l.Table))
+}
+
+// initialize creates the table if it does not exist.
+func (l *locker) initialize() error {
+	_, err := l.Database.Execute(l.statement("CREATE TABLE IF NOT EXISTS %s (name VARCHAR(255) NOT NULL PRIMARY KEY, activation BIGINT NOT NULL, owner VARCHAR(36) NOT NULL, expires BIGINT NOT NULL)"))
+	if err != nil {
+		return errors.Wrapf(err, "jobs: could not create table %q for job locker", l.Table)
+	}
+
+	return nil
+}
+
+// exists reports whether the lease of job exists.
+func (l *locker) exists(name string) (bool, error) {
+	rows, err := l.Database.Query(l.statement("SELECT name FROM %s WHERE name = ?"), name)
+	if err != nil {
+		return false, err
+	}
+	defer sql2.CloseRows(rows)
+
+	return rows.Next(), nil
+}
+
+// Lock tries to acquire the lease by updating the released/expired row of previous activation.
+// It inserts the row if the job has never been locked, the concurrent insertions fail on the primary key.
+func (l *locker) Lock(name string, activation time.Time, lease time.Duration) (bool, error) {
+	now := time.Now()
+	n, err := l.Database.Execute(l.statement("UPDATE %s SET activation = ?, owner = ?, expires = ? WHERE name = ? AND activation < ? AND expires < ?"),
+		activation.UnixNano(), l.Owner, now.Add(lease).UnixNano(), name, activation.UnixNano(), now.UnixNano())
+	if err != nil {
+		return false, errors.Wrapf(err, "jobs: could not acquire lease of job %q", name)
+	}
+	if n > 0 {
+		return true, nil
+	}
+
+	_, err = l.Database.Execute(l.statement("INSERT INTO %s (name, activation, owner, expires) VALUES (?, ?, ?, ?)"),
+		name, activation.UnixNano(), l.Owner, now.Add(lease).UnixNano())
+	if err != nil {
+		exists, e := l.exists(name)
+		if e != nil {
+			return false, errors.Wrapf(err, "jobs: could not acquire lease of job %q", name)
+		}
+		if exists { // held by another node
+			return false, nil
+		}
+
+		return false, errors.Wrapf(err, "jobs: could not acquire lease of job %q", name)
+	}
+
+	return true, nil
+}
+
+func (l *locker) Renew(name string, activation time.Time, lease time.Duration) (bool, error) {
+	now := time.Now()
+	n, err := l.Database.Execute(l.statement("UPDATE %s SET expires = ? WHERE name = ? AND activation = ? AND owner = ? AND expires >= ?"),
+		now.Add(lease).UnixNano(), name, activation.UnixNano(), l.Owner, now.UnixNano())
+	if err != nil {
+		return false, errors.Wrapf(err, "jobs: could not renew lease of job %q", name)
+	}
+
+	return n > 0, nil
+}
+
+// Unlock releases the lease, the row is kept for rejecting the late nodes of same activation.
+func (l *locker) Unlock(name string, activation time.Time) error {
+	_, err := l.Database.Execute(l.statement("UPDATE %s SET expires = 0 WHERE name = ? AND activation = ? AND owner = ?"),
+		name, activation.UnixNano(), l.Owner)
+	if err != nil {
+		return errors.Wrapf(err, "jobs: could not release lease of job %q", name)
+	}
+
+	return nil
+}
+
+// NewLocker returns a new job locker holding the leases in given table (DefaultTable if empty).
+// The table will be created if it does not exist.
+func NewLocker(db sql2.Database, table string, placeholder sql2.Placeholder) (jobs.Locker, error) {
+	if db == nil {
+		return nil, errors.New("jobs: database of job locker cannot be nil")
+	}
+	if len(table) == 0 {
+		table = DefaultTable
+	}
+
+	owner, err := uuid.NewRandom()
+	if err != nil {
+		return nil, errors.Wrap(err, "jobs: could not generate owner of job locker")
+	}
+
+	l := &locker{
+		Database:    db,
+		Table:       table,
+		Placeholder: placeholder,
+		Owner:       owner.String(),
+	}
+	err = l.initialize()
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// New is short to NewLocker func.
+func New(db sql2.Database, table string, placeholder sql2.Placeholder) (jobs.Locker, error) {
+	return NewLocker(db, table, placeholder)
+}
@@ -0,0 +1,49 @@
+package sql
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	sql2 "github.com/wayn3h0/gop/sql"
+	"github.com/wayn3h0/gop/sql/sqlite"
+	testing2 "github.com/wayn3h0/gop/testing"
+)
+
+func TestLocker(t *testing.T) {
+	db, err := sqlite.NewDatabase(filepath.Join(t.TempDir(), "jobs.db"))
+	testing2.AssertEqual(t, err, nil)
+
+	l1, err := NewLocker(db, "", sql2.PlaceholderQuestion) // node 1
+	testing2.AssertEqual(t, err, nil)
+	l2, err := NewLocker(db, "", sql2.PlaceholderQuestion) // node 2
+	testing2.AssertEqual(t, err, nil)
+
+	t1 := time.Now().Truncate(time.Hour)
+	t2 := t1.Add(time.Hour)
+
+	acquired, err := l1.Lock("job", t1, time.Minute)
+	testing2.AssertEqual(t, err, nil)
+	testing2.ExpectEqual(t, acquired, true)
+	acquired, err = l2.Lock("job", t1, time.Minute)
+	testing2.AssertEqual(t, err, nil)
+	testing2.ExpectEqual(t, acquired, false)
+	acquired, _ = l2.Lock("job", t2, time.Minute) // previous lease is held
+	testing2.ExpectEqual(t, acquired, false)
+
+	held, _ := l1.Renew("job", t1, time.Minute)
+	testing2.ExpectEqual(t, held, true)
+	held, _ = l2.Renew("job", t1, time.Minute) // not owner
+	testing2.ExpectEqual(t, held, false)
+
+	l1.Unlock("job", t1)
+	acquired, _ = l2.Lock("job", t1, time.Minute) // released but same activation
+	testing2.ExpectEqual(t, acquired, false)
+	acquired, _ = l2.Lock("job", t2, time.Millisecond)
+	testing2.ExpectEqual(t, acquired, true)
+
+	// crashed node (lease expired)
+	time.Sleep(5 * time.Millisecond)
+	acquired, _ = l1.Lock("job", t2.Add(time.Hour), time.Minute)
+	testing2.ExpectEqual(t, acquired, true)
+}
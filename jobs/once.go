@@ -0,0 +1,144 @@
+package jobs
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/wayn3h0/gop/errors"
+	"github.com/wayn3h0/gop/jobs/expression"
+	"github.com/wayn3h0/gop/uuid"
+)
+
+// Register registers the handler by name.
+// The handler runs the jobs set it by WithHandler, it's required for restoring the pending one-off jobs from store.
+func (s *Scheduler) Register(name string, fn func()) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.handlers[name] = fn
+}
+
+// ScheduleAt adds a one-off job runs at given time, it returns the name of job for cancelling.
+// A random name is generated if the job has no name, the job is removed after it completes.
+// The pending job is persisted if the scheduler has a store and the job sets a registered handler by WithHandler.
+// The persisted job runs at least once, it runs again on restart if the process exits before it completes.
+func (s *Scheduler) ScheduleAt(fn func(), at time.Time, options ...JobOption) (string, error) {
+	job := &job{
+		Function:   fn,
+		Expression: expression.Once(at),
+		At:         at,
+	}
+	for _, option := range options {
+		option(job)
+	}
+	if len(job.Name) == 0 {
+		id, err := uuid.NewRandom()
+		if err != nil {
+			return "", errors.Wrap(err, "jobs: could not generate name of one-off job")
+		}
+		job.Name = id.String()
+	}
+
+	if s.store != nil && len(job.Handler) > 0 {
+		err := s.store.Save(&JobRecord{
+			Name:    job.Name,
+			Handler: job.Handler,
+			At:      job.At,
+		})
+		if err != nil {
+			return "", errors.Wrapf(err, "jobs: could not save record of job %q to store", job.Name)
+		}
+	}
+
+	if !s.add(job) {
+		s.forget(job)
+		return "", errors.Newf("jobs: function of job %q is nil and handler %q is not registered", job.Name, job.Handler)
+	}
+
+	return job.Name, nil
+}
+
+// ScheduleOnce adds a one-off job runs after given delay.
+// This is short for ScheduleAt with the current time plus delay.
+func (s *Scheduler) ScheduleOnce(fn func(), delay time.Duration, options ...JobOption) (string, error) {
+	return s.ScheduleAt(fn, s.clock.Now().Add(delay), options...)
+}
+
+// Cancel removes the job by name before it activates, it reports whether the job found.
+// The record of one-off job is removed from the store.
+func (s *Scheduler) Cancel(name string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, job := range s.jobs {
+		if job.Name != name {
+			continue
+		}
+
+		if s.running {
+			heap.Remove(&s.jobs, i)
+		} else {
+			s.jobs = append(s.jobs[:i], s.jobs[i+1:]...)
+		}
+		if !job.At.IsZero() {
+			s.forget(job)
+		}
+		return true
+	}
+
+	return false
+}
+
+// forget removes the record of one-off job from store.
+func (s *Scheduler) forget(job *job) {
+	if s.store == nil {
+		return
+	}
+
+	err := s.store.Remove(job.Name)
+	if err != nil {
+		s.logger.Errorf("jobs: could not remove record of job %q from store: %s", job.Name, err)
+	}
+}
+
+// restore adds the pending one-off jobs from store, the mutex should be held.
+func (s *Scheduler) restore() {
+	if s.store == nil {
+		return
+	}
+
+	records, err := s.store.List()
+	if err != nil {
+		s.logger.Errorf("jobs: could not list records of jobs from store: %s", err)
+		return
+	}
+
+	names := make(map[string]bool)
+	for _, job := range s.jobs {
+		names[job.Name] = true
+	}
+	for _, record := range records {
+		if record.At.IsZero() || names[record.Name] {
+			continue
+		}
+
+		if len(record.Handler) == 0 {
+			// the interrupted one-off job with function cannot be restored
+			s.logger.Warnf("jobs: could not restore job %q without handler, the record is removed", record.Name)
+			s.forget(&job{Name: record.Name})
+			continue
+		}
+		fn, ok := s.handlers[record.Handler]
+		if !ok {
+			s.logger.Warnf("jobs: could not restore job %q, handler %q is not registered", record.Name, record.Handler)
+			continue
+		}
+		s.jobs = append(s.jobs, &job{
+			Function:   fn,
+			Expression: expression.Once(record.At),
+			Name:       record.Name,
+			Handler:    record.Handler,
+			At:         record.At,
+		})
+	}
+}
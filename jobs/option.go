@@ -0,0 +1,113 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/wayn3h0/gop/clock"
+	"github.com/wayn3h0/gop/log"
+)
+
+// Option represents an option of scheduler.
+type Option func(*Scheduler)
+
+// WithStore sets the store for persisting the state of named jobs.
+func WithStore(store JobStore) Option {
+	return func(s *Scheduler) {
+		s.store = store
+	}
+}
+
+// WithLocker sets the locker for running each activation of named jobs on exactly one node.
+// The lease will be renewed periodically while the job is running, DefaultLease used if lease is not positive.
+func WithLocker(locker Locker, lease time.Duration) Option {
+	return func(s *Scheduler) {
+		if lease <= 0 {
+			lease = DefaultLease
+		}
+		s.locker = locker
+		s.lease = lease
+	}
+}
+
+// WithLogger sets the logger for reporting errors.
+func WithLogger(logger *log.Logger) Option {
+	return func(s *Scheduler) {
+		s.logger = logger
+	}
+}
+
+// WithClock sets the clock for the current time and timers, clock.Default used if nil.
+// It's useful to drive the scheduler by a fake clock in testing.
+func WithClock(c clock.Clock) Option {
+	return func(s *Scheduler) {
+		if c == nil {
+			c = clock.Default
+		}
+		s.clock = c
+	}
+}
+
+// WithPool sets the number of workers of named pool, the pool will be created if it does not exist.
+// The DefaultPool can be resized by this option, the jobs run in the pool set by WithPoolName.
+func WithPool(name string, size int) Option {
+	return func(s *Scheduler) {
+		s.sizes[name] = size
+	}
+}
+
+// WithHistory sets the number of executions kept in history, the history is disabled if size is not positive.
+func WithHistory(size int) Option {
+	return func(s *Scheduler) {
+		s.history.size = size
+	}
+}
+
+// JobOption represents an option of job.
+type JobOption func(*job)
+
+// WithName sets the name of job.
+// The name identifies the job in the store, it should be unique in the scheduler.
+func WithName(name string) JobOption {
+	return func(j *job) {
+		j.Name = name
+	}
+}
+
+// WithMisfirePolicy sets the policy for the activations missed while the scheduler was down.
+// It requires a named job and a store.
+func WithMisfirePolicy(policy MisfirePolicy) JobOption {
+	return func(j *job) {
+		j.MisfirePolicy = policy
+	}
+}
+
+// WithMaxRuns sets the maximum number of runs of job, the job never activates again after it runs max times.
+// Unlike expression.Limit, only the activations actually run are counted (including the missed ones run by the misfire policy),
+// the runs are counted since the scheduler started.
+func WithMaxRuns(max int) JobOption {
+	return func(j *job) {
+		j.MaxRuns = max
+	}
+}
+
+// WithPriority sets the priority of job, the job with higher priority runs first if the pool is busy.
+func WithPriority(priority int) JobOption {
+	return func(j *job) {
+		j.Priority = priority
+	}
+}
+
+// WithPoolName sets the pool the job runs in, the DefaultPool used if the pool does not exist.
+func WithPoolName(name string) JobOption {
+	return func(j *job) {
+		j.Pool = name
+	}
+}
+
+// WithHandler sets the registered handler of job, it runs the handler if the job has no function.
+// The pending one-off jobs with handler are restored from the store when the scheduler starts.
+func WithHandler(name string) JobOption {
+	return func(j *job) {
+		j.Handler = name
+	}
+}
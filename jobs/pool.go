@@ -0,0 +1,196 @@
+package jobs
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wayn3h0/gop/clock"
+)
+
+// DefaultPool is the name of default pool, the jobs without pool run in it.
+const DefaultPool = ""
+
+// DefaultPoolSize is the default number of workers of default pool.
+const DefaultPoolSize = 64
+
+// task represents a queued function of pool.
+type task struct {
+	Function func()
+	Priority int
+	Sequence uint64
+	Queued   time.Time
+}
+
+// tasks represents a max-heap of task ordered by priority, the tasks with same priority are in FIFO order.
+type tasks []*task
+
+// Len implements heap.Interface.
+func (t tasks) Len() int {
+	return len(t)
+}
+
+// Swap implements heap.Interface.
+func (t tasks) Swap(x, y int) {
+	t[x], t[y] = t[y], t[x]
+}
+
+// Less implements heap.Interface.
+func (t tasks) Less(x, y int) bool {
+	if t[x].Priority != t[y].Priority {
+		return t[x].Priority > t[y].Priority
+	}
+
+	return t[x].Sequence < t[y].Sequence
+}
+
+// Push implements heap.Interface.
+func (t *tasks) Push(x interface{}) {
+	*t = append(*t, x.(*task))
+}
+
+// Pop implements heap.Interface.
+func (t *tasks) Pop() interface{} {
+	old := *t
+	n := len(old)
+	task := old[n-1]
+	old[n-1] = nil
+	*t = old[:n-1]
+
+	return task
+}
+
+// PoolStats represents the statistics of pool.
+type PoolStats struct {
+	Name     string
+	Size     int           // max number of workers
+	Running  int           // number of running workers
+	Depth    int           // number of queued tasks
+	Executed int64         // number of started tasks
+	Wait     time.Duration // total time the started tasks waited in queue
+	MaxWait  time.Duration // max time a started task waited in queue
+	Oldest   time.Duration // time the oldest queued task has been waiting
+}
+
+// AverageWait returns the average time the started tasks waited in queue.
+func (s PoolStats) AverageWait() time.Duration {
+	if s.Executed == 0 {
+		return 0
+	}
+
+	return s.Wait / time.Duration(s.Executed)
+}
+
+// pool represents a bounded worker pool runs the tasks by priority.
+// The workers are started on demand and exit when the queue is empty.
+type pool struct {
+	mutex    sync.Mutex
+	name     string
+	size     int
+	clock    clock.Clock
+	queue    tasks
+	sequence uint64
+	running  int
+	executed int64
+	wait     time.Duration
+	maxWait  time.Duration
+}
+
+// submit queues the function with priority, the higher priority runs first.
+func (p *pool) submit(fn func(), priority int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.sequence++
+	heap.Push(&p.queue, &task{
+		Function: fn,
+		Priority: priority,
+		Sequence: p.sequence,
+		Queued:   p.clock.Now(),
+	})
+	if p.running < p.size {
+		p.running++
+		go p.work()
+	}
+}
+
+// work runs the queued tasks until the queue is empty.
+func (p *pool) work() {
+	for {
+		p.mutex.Lock()
+		if len(p.queue) == 0 {
+			p.running--
+			p.mutex.Unlock()
+			return
+		}
+		task := heap.Pop(&p.queue).(*task)
+		wait := p.clock.Now().Sub(task.Queued)
+		p.executed++
+		p.wait += wait
+		if wait > p.maxWait {
+			p.maxWait = wait
+		}
+		p.mutex.Unlock()
+
+		task.Function()
+	}
+}
+
+// stats returns the statistics of pool.
+func (p *pool) stats() PoolStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	stats := PoolStats{
+		Name:     p.name,
+		Size:     p.size,
+		Running:  p.running,
+		Depth:    len(p.queue),
+		Executed: p.executed,
+		Wait:     p.wait,
+		MaxWait:  p.maxWait,
+	}
+	for _, task := range p.queue {
+		if oldest := p.clock.Now().Sub(task.Queued); oldest > stats.Oldest {
+			stats.Oldest = oldest
+		}
+	}
+
+	return stats
+}
+
+// newPool returns a new pool with given number of workers.
+func newPool(name string, size int, clock clock.Clock) *pool {
+	if size <= 0 {
+		size = 1
+	}
+
+	return &pool{
+		name:  name,
+		size:  size,
+		clock: clock,
+	}
+}
+
+// pool returns the pool of job, it falls back to default pool if the pool is not found.
+func (s *Scheduler) pool(job *job) *pool {
+	if p, ok := s.pools[job.Pool]; ok {
+		return p
+	}
+
+	return s.pools[DefaultPool]
+}
+
+// Pools returns the statistics of pools ordered by name.
+func (s *Scheduler) Pools() []PoolStats {
+	var list []PoolStats
+	for _, p := range s.pools {
+		list = append(list, p.stats())
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	testing2 "github.com/wayn3h0/gop/testing"
+)
+
+func TestPool(t *testing.T) {
+	clock := testing2.NewFakeClock(time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC))
+	p := newPool("io", 1, clock)
+
+	// blocks the only worker
+	started := make(chan bool)
+	release := make(chan bool)
+	p.submit(func() {
+		started <- true
+		<-release
+	}, 0)
+	<-started
+
+	var mutex sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for _, priority := range []int{1, 3, 2, 3} {
+		priority := priority
+		wg.Add(1)
+		p.submit(func() {
+			mutex.Lock()
+			order = append(order, priority)
+			mutex.Unlock()
+			wg.Done()
+		}, priority)
+	}
+
+	clock.Advance(time.Second)
+	stats := p.stats()
+	testing2.ExpectEqual(t, stats.Name, "io")
+	testing2.ExpectEqual(t, stats.Running, 1)
+	testing2.ExpectEqual(t, stats.Depth, 4)
+	testing2.ExpectEqual(t, stats.Executed, int64(1))
+	testing2.ExpectEqual(t, stats.Oldest, time.Second)
+
+	close(release)
+	wg.Wait()
+	testing2.ExpectEqual(t, order, []int{3, 3, 2, 1})
+
+	stats = p.stats()
+	testing2.ExpectEqual(t, stats.Depth, 0)
+	testing2.ExpectEqual(t, stats.Executed, int64(5))
+	testing2.ExpectEqual(t, stats.MaxWait, time.Second)
+	testing2.ExpectEqual(t, stats.AverageWait(), 4*time.Second/5)
+}
+
+func TestSchedulerPools(t *testing.T) {
+	s := NewScheduler(WithPool("io", 8), WithPool("cpu", 2))
+	pools := s.Pools()
+	testing2.AssertEqual(t, len(pools), 3)
+	for i, want := range []PoolStats{{Name: DefaultPool, Size: DefaultPoolSize}, {Name: "cpu", Size: 2}, {Name: "io", Size: 8}} {
+		testing2.ExpectEqualL(t, pools[i], want, i)
+	}
+
+	testing2.ExpectEqual(t, s.pool(&job{Pool: "cpu"}).name, "cpu")
+	testing2.ExpectEqual(t, s.pool(&job{Pool: "gpu"}).name, DefaultPool)
+}
@@ -1,99 +1,276 @@
 package jobs
 
 import (
+	"container/heap"
+	"sync"
 	"time"
 
+	"github.com/wayn3h0/gop/clock"
 	"github.com/wayn3h0/gop/jobs/expression"
+	"github.com/wayn3h0/gop/log"
 )
 
 // Scheduler represents a job scheduler.
+// The due jobs run in bounded worker pools, the DefaultPool used unless the job sets another one by WithPoolName.
 type Scheduler struct {
-	jobs    jobs
-	running bool
-	add     chan *job
-	stop    chan bool
+	mutex    sync.Mutex
+	jobs     jobs
+	running  bool
+	timer    clock.Timer // timer of the loop, re-armed synchronously when a job becomes due earlier
+	stop     chan bool
+	store    JobStore
+	locker   Locker
+	lease    time.Duration
+	logger   *log.Logger
+	clock    clock.Clock
+	sizes    map[string]int
+	pools    map[string]*pool
+	handlers map[string]func()
+	history  *history
 }
 
-func (s *Scheduler) run() {
-	go func() {
-		now := time.Now()
-		for _, job := range s.jobs {
-			job.Next = job.Expression.Next(now)
+// prepare restores the state of job from store and computes the next activated time.
+// The missed activations are handled by the misfire policy of job, the overdue or interrupted one-off job is due immediately.
+func (s *Scheduler) prepare(job *job, now time.Time) {
+	if s.store != nil && len(job.Name) > 0 {
+		record, err := s.store.Get(job.Name)
+		if err != nil {
+			s.logger.Errorf("jobs: could not get record of job %q from store: %s", job.Name, err)
+		}
+		if record != nil && !record.Previous.IsZero() {
+			job.Previous = record.Previous
+			var missed []time.Time
+			if job.MisfirePolicy != MisfireSkip && job.At.IsZero() {
+				var skipped int
+				missed, skipped = misfires(job, record.Previous, now)
+				if skipped > 0 && job.MisfirePolicy == MisfireRunAll {
+					s.logger.Warnf("jobs: %d missed activations of job %q are skipped, only the latest %d run", skipped, job.Name, MaxMisfires)
+				}
+			}
+			if job.MaxRuns > 0 && len(missed) > job.MaxRuns-job.Runs {
+				missed = missed[:job.MaxRuns-job.Runs]
+			}
+			if len(missed) > 0 {
+				s.pool(job).submit(func() {
+					for _, activation := range missed {
+						s.execute(job, activation)
+					}
+				}, job.Priority)
+				s.save(job, missed[len(missed)-1])
+				job.Runs += len(missed)
+			}
 		}
+	}
 
-		for {
-			s.jobs.Sort()
+	if !job.At.IsZero() {
+		// the record is removed after the job completes, so the job activated before was interrupted (e.g. the process crashed)
+		if !job.Previous.Before(job.At) {
+			s.logger.Warnf("jobs: one-off job %q was interrupted, it runs again", job.Name)
+		}
+		job.Next = job.At
+		return
+	}
 
-			var effective time.Time
-			if len(s.jobs) == 0 || s.jobs[0].Next.IsZero() {
-				effective = now.AddDate(10, 0, 0)
-			} else {
-				effective = s.jobs[0].Next
-			}
+	job.Next = job.Expression.Next(now)
+	if job.MaxRuns > 0 && job.Runs >= job.MaxRuns {
+		job.Next = time.Time{}
+	}
+}
 
-			select {
-			case now = <-time.After(effective.Sub(now)):
-				for _, job := range s.jobs {
-					if job.Next != effective {
-						break
-					}
+// save persists the activated time of job.
+func (s *Scheduler) save(job *job, activated time.Time) {
+	job.Previous = activated
+	if s.store == nil || len(job.Name) == 0 {
+		return
+	}
+
+	err := s.store.Save(&JobRecord{
+		Name:     job.Name,
+		Previous: activated,
+		Handler:  job.Handler,
+		At:       job.At,
+	})
+	if err != nil {
+		s.logger.Errorf("jobs: could not save record of job %q to store: %s", job.Name, err)
+	}
+}
+
+// wait returns the duration until the next activation, the mutex should be held.
+func (s *Scheduler) wait(now time.Time) time.Duration {
+	if len(s.jobs) == 0 || s.jobs[0].Next.IsZero() {
+		return now.AddDate(10, 0, 0).Sub(now)
+	}
+
+	return s.jobs[0].Next.Sub(now)
+}
+
+// rearm re-arms the timer of loop for the earliest job, the mutex should be held.
+func (s *Scheduler) rearm(now time.Time) {
+	s.timer.Reset(s.wait(now))
+}
 
-					go job.Function()
+// dispatch activates all the jobs due at given time, the mutex should be held.
+// The activations missed by a late timer are skipped except the earliest one of each job, and the paused jobs are skipped,
+// the paused one-off job is kept pending.
+func (s *Scheduler) dispatch(now time.Time) {
+	for len(s.jobs) > 0 {
+		job := s.jobs[0]
+		if job.Next.IsZero() || job.Next.After(now) {
+			return
+		}
 
-					job.Previous = job.Next
-					job.Next = job.Expression.Next(effective)
+		activation := job.Next
+		if job.Paused && !job.At.IsZero() {
+			// the paused one-off job stays pending at the bottom until it resumes
+			job.Next = time.Time{}
+			heap.Fix(&s.jobs, 0)
+			continue
+		}
+		if !job.Paused {
+			s.pool(job).submit(func() {
+				s.execute(job, activation)
+				if !job.At.IsZero() {
+					s.forget(job)
 				}
-				continue
+			}, job.Priority)
+			s.save(job, activation)
+			job.Runs++
+		}
+
+		job.Next = job.Expression.Next(activation)
+		if !job.Next.IsZero() && !job.Next.After(now) {
+			job.Next = job.Expression.Next(now)
+		}
+		if job.MaxRuns > 0 && job.Runs >= job.MaxRuns {
+			job.Next = time.Time{}
+		}
+		if job.Next.IsZero() { // never activates again
+			heap.Pop(&s.jobs)
+		} else {
+			heap.Fix(&s.jobs, 0)
+		}
+	}
+}
 
-			case job := <-s.add:
-				s.jobs = append(s.jobs, job)
-				job.Next = job.Expression.Next(now)
+func (s *Scheduler) run() {
+	s.mutex.Lock()
+	s.running = true
+	s.restore()
+	now := s.clock.Now()
+	for _, job := range s.jobs {
+		s.prepare(job, now)
+	}
+	heap.Init(&s.jobs)
+	timer := s.clock.NewTimer(s.wait(now))
+	s.timer = timer
+	s.mutex.Unlock()
 
+	go func() {
+		for {
+			select {
+			case <-timer.C(): // may be stale after re-armed, dispatching at any time is harmless
 			case <-s.stop:
+				timer.Stop()
+				s.stop <- true // acknowledges the loop exited
 				return
 			}
 
-			now = time.Now()
+			s.mutex.Lock()
+			now := s.clock.Now()
+			s.dispatch(now)
+			timer.Reset(s.wait(now))
+			s.mutex.Unlock()
 		}
 	}()
 }
 
-// Schedule adds a job to the scheduler.
-func (s *Scheduler) Schedule(fn func(), expr expression.Expression) {
-	if fn != nil {
-		job := &job{
-			Function:   fn,
-			Expression: expr,
-		}
+// add adds the job to the scheduler, the function of registered handler used if the job has neither function nor workflow.
+// The expression is named by the name of job if it supports.
+func (s *Scheduler) add(job *job) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
-		if !s.running {
-			s.jobs = append(s.jobs, job)
-		} else {
-			s.add <- job
+	if job.Function == nil && job.Workflow == nil {
+		job.Function = s.handlers[job.Handler]
+		if job.Function == nil {
+			return false
 		}
 	}
+	if named, ok := job.Expression.(expression.Named); ok && len(job.Name) > 0 {
+		job.Expression = named.WithName(job.Name)
+	}
+
+	if !s.running {
+		s.jobs = append(s.jobs, job)
+		return true
+	}
+
+	now := s.clock.Now()
+	s.prepare(job, now)
+	heap.Push(&s.jobs, job)
+	s.rearm(now)
+
+	return true
+}
+
+// Schedule adds a job to the scheduler.
+// The function can be nil if the job sets a registered handler by WithHandler.
+func (s *Scheduler) Schedule(fn func(), expr expression.Expression, options ...JobOption) {
+	job := &job{
+		Function:   fn,
+		Expression: expr,
+	}
+	for _, option := range options {
+		option(job)
+	}
+
+	s.add(job)
 }
 
 // Start starts the scheduler for scheduling tasks.
 func (s *Scheduler) Start() {
-	s.running = true
 	s.run()
 }
 
-// Stop stops scheduler.
+// Stop stops scheduler, no more jobs are activated after it returns.
+// It returns immediately if the scheduler is not running.
 func (s *Scheduler) Stop() {
+	s.mutex.Lock()
+	running := s.running
+	s.mutex.Unlock()
+	if !running {
+		return
+	}
+
 	s.stop <- true
+	<-s.stop
+
+	s.mutex.Lock()
 	s.running = false
+	s.mutex.Unlock()
 }
 
 // NewScheduler returns a new scheduler.
-func NewScheduler() *Scheduler {
-	return &Scheduler{
-		jobs:    nil,
-		running: false,
-		add:     make(chan *job),
-		stop:    make(chan bool),
+func NewScheduler(options ...Option) *Scheduler {
+	s := &Scheduler{
+		jobs:     nil,
+		running:  false,
+		stop:     make(chan bool),
+		logger:   log.DefaultLogger,
+		clock:    clock.Default,
+		sizes:    map[string]int{DefaultPool: DefaultPoolSize},
+		pools:    make(map[string]*pool),
+		handlers: make(map[string]func()),
+		history:  &history{size: DefaultHistorySize},
+	}
+	for _, option := range options {
+		option(s)
 	}
+	for name, size := range s.sizes {
+		s.pools[name] = newPool(name, size, s.clock)
+	}
+
+	return s
 }
 
 var (
@@ -103,8 +280,8 @@ var (
 
 // Schedule adds a job to default scheduler.
 // This is short for DefaultScheduler.Schedule.
-func Schedule(fn func(), expr expression.Expression) {
-	DefaultScheduler.Schedule(fn, expr)
+func Schedule(fn func(), expr expression.Expression, options ...JobOption) {
+	DefaultScheduler.Schedule(fn, expr, options...)
 }
 
 // Start starts the default scheduler.
@@ -0,0 +1,441 @@
+package jobs
+
+import (
+	"container/heap"
+	"io/ioutil"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wayn3h0/gop/jobs/expression"
+	"github.com/wayn3h0/gop/jobs/expression/cron"
+	"github.com/wayn3h0/gop/jobs/expression/cycle"
+	"github.com/wayn3h0/gop/log"
+	testing2 "github.com/wayn3h0/gop/testing"
+)
+
+type store struct {
+	sync.Mutex
+	records map[string]JobRecord
+}
+
+func (s *store) Get(name string) (*JobRecord, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if record, ok := s.records[name]; ok {
+		return &record, nil
+	}
+
+	return nil, nil
+}
+
+func (s *store) Save(record *JobRecord) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.records[record.Name] = *record
+
+	return nil
+}
+
+func (s *store) Remove(name string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.records, name)
+
+	return nil
+}
+
+func (s *store) List() ([]*JobRecord, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	var records []*JobRecord
+	for _, record := range s.records {
+		record := record
+		records = append(records, &record)
+	}
+
+	return records, nil
+}
+
+func TestSchedulerMisfire(t *testing.T) {
+	previous := time.Now().Add(-210 * time.Minute)
+	policies := map[MisfirePolicy]int32{
+		MisfireSkip:    0,
+		MisfireRunOnce: 1,
+		MisfireRunAll:  3,
+	}
+	for policy, want := range policies {
+		st := &store{
+			records: map[string]JobRecord{
+				"job": {Name: "job", Previous: previous},
+			},
+		}
+		s := NewScheduler(WithStore(st))
+
+		var count int32
+		s.Schedule(func() {
+			atomic.AddInt32(&count, 1)
+		}, cycle.NewExpression(time.Hour), WithName("job"), WithMisfirePolicy(policy))
+		s.Start()
+		time.Sleep(50 * time.Millisecond)
+		s.Stop()
+
+		testing2.ExpectEqualL(t, atomic.LoadInt32(&count), want, int(policy))
+
+		record, _ := st.Get("job")
+		if want > 0 { // the latest missed activation
+			testing2.ExpectEqualL(t, record.Previous, previous.Add(3*time.Hour), int(policy))
+		} else {
+			testing2.ExpectEqualL(t, record.Previous, previous, int(policy))
+		}
+	}
+}
+
+func TestMisfires(t *testing.T) {
+	from := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(MaxMisfires*time.Second + 10*time.Second + time.Millisecond)
+	job := &job{
+		Expression: cycle.NewExpression(time.Second),
+	}
+
+	job.MisfirePolicy = MisfireRunOnce
+	missed, skipped := misfires(job, from, to)
+	testing2.ExpectEqual(t, missed, []time.Time{from.Add(MaxMisfires*time.Second + 10*time.Second)})
+	testing2.ExpectEqual(t, skipped, MaxMisfires+9)
+
+	job.MisfirePolicy = MisfireRunAll
+	missed, skipped = misfires(job, from, to)
+	testing2.AssertEqual(t, len(missed), MaxMisfires)
+	testing2.ExpectEqual(t, skipped, 10)
+	testing2.ExpectEqual(t, missed[0], from.Add(11*time.Second))
+	testing2.ExpectEqual(t, missed[MaxMisfires-1], from.Add(MaxMisfires*time.Second+10*time.Second))
+
+	missed, skipped = misfires(job, from, from.Add(3*time.Second))
+	testing2.ExpectEqual(t, missed, []time.Time{from.Add(time.Second), from.Add(2 * time.Second), from.Add(3 * time.Second)})
+	testing2.ExpectEqual(t, skipped, 0)
+}
+
+func receive(ch chan time.Time, timeout time.Duration) (time.Time, bool) {
+	select {
+	case t := <-ch:
+		return t, true
+	case <-time.After(timeout):
+		return time.Time{}, false
+	}
+}
+
+func TestSchedulerClock(t *testing.T) {
+	start := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+	clock := testing2.NewFakeClock(start)
+	s := NewScheduler(WithClock(clock))
+
+	expr, err := cron.NewExpression("0 2 * * *")
+	testing2.AssertEqual(t, err, nil)
+	activations := make(chan time.Time, 1)
+	s.Schedule(func() {
+		activations <- clock.Now()
+	}, expr)
+	s.Start()
+	defer s.Stop()
+
+	for i, want := range []time.Time{start.Add(2 * time.Hour), start.Add(26 * time.Hour)} {
+		clock.BlockUntil(1)
+		clock.Advance(want.Sub(clock.Now()) - time.Second)
+		_, ok := receive(activations, 50*time.Millisecond)
+		testing2.ExpectEqualL(t, ok, false, i)
+
+		clock.Advance(time.Second)
+		have, ok := receive(activations, time.Second)
+		testing2.AssertEqualL(t, ok, true, i)
+		testing2.ExpectEqualL(t, have, want, i)
+	}
+}
+
+func TestSchedulerNamedExpression(t *testing.T) {
+	start := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+	s := NewScheduler(WithClock(testing2.NewFakeClock(start)))
+	e, err := cron.NewExpression("H H * * *")
+	testing2.AssertEqual(t, err, nil)
+	for _, name := range []string{"backup", "report"} {
+		s.Schedule(func() {}, e, WithName(name))
+	}
+	s.Start()
+	defer s.Stop()
+
+	for i, job := range s.Jobs() {
+		named, _ := cron.Parse("H H * * *", job.Name, nil)
+		testing2.ExpectEqualL(t, job.Next, named.Next(start), i)
+	}
+}
+
+func TestSchedulerAdd(t *testing.T) {
+	clock := testing2.NewFakeClock(time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC))
+	s := NewScheduler(WithClock(clock))
+	s.Start()
+	defer s.Stop()
+	clock.BlockUntil(1)
+
+	activations := make(chan time.Time, 1)
+	s.Schedule(func() {
+		activations <- clock.Now()
+	}, cycle.NewExpression(time.Minute))
+
+	// the timer is re-armed before Schedule returns
+	clock.Advance(time.Minute)
+	_, ok := receive(activations, time.Second)
+	testing2.ExpectEqual(t, ok, true)
+}
+
+func TestSchedulerStop(t *testing.T) {
+	clock := testing2.NewFakeClock(time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC))
+	s := NewScheduler(WithClock(clock))
+
+	activations := make(chan time.Time, 1)
+	s.Schedule(func() {
+		activations <- clock.Now()
+	}, cycle.NewExpression(time.Minute))
+	s.Start()
+	clock.BlockUntil(1)
+	s.Stop()
+
+	testing2.ExpectEqual(t, clock.Timers(), 0)
+	clock.Advance(time.Hour)
+	_, ok := receive(activations, 50*time.Millisecond)
+	testing2.ExpectEqual(t, ok, false)
+
+	s.Stop() // not running
+}
+
+func TestSchedulerDispatch(t *testing.T) {
+	start := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+	clock := testing2.NewFakeClock(start)
+	s := NewScheduler(WithClock(clock))
+
+	// the jobs are due at slightly different instants
+	var counts [3]int32
+	for i := range counts {
+		count := &counts[i]
+		s.Schedule(func() {
+			atomic.AddInt32(count, 1)
+		}, cycle.NewExpression(time.Minute+time.Duration(i)*time.Millisecond))
+	}
+	s.Start()
+	defer s.Stop()
+
+	clock.BlockUntil(1)
+	clock.Advance(90 * time.Second)
+	time.Sleep(50 * time.Millisecond)
+	for i := range counts {
+		testing2.ExpectEqualL(t, atomic.LoadInt32(&counts[i]), int32(1), i)
+	}
+
+	// the missed activations of a late timer are skipped
+	clock.BlockUntil(1)
+	clock.Advance(10 * time.Minute)
+	time.Sleep(50 * time.Millisecond)
+	for i := range counts {
+		testing2.ExpectEqualL(t, atomic.LoadInt32(&counts[i]), int32(2), i)
+	}
+}
+
+func TestSchedulerMaxRuns(t *testing.T) {
+	clock := testing2.NewFakeClock(time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC))
+	s := NewScheduler(WithClock(clock))
+
+	var limited, maxRuns int32
+	s.Schedule(func() {
+		atomic.AddInt32(&limited, 1)
+	}, expression.Limit(cycle.NewExpression(time.Minute), clock.Now(), 3))
+	s.Schedule(func() {
+		atomic.AddInt32(&maxRuns, 1)
+	}, cycle.NewExpression(time.Minute), WithMaxRuns(3))
+	s.Start()
+	defer s.Stop()
+
+	// the late timer skips the activation at 00:02, which is counted by Limit but not by WithMaxRuns
+	for _, d := range []time.Duration{61 * time.Second, 2 * time.Minute, time.Minute, time.Minute, time.Minute} {
+		clock.BlockUntil(1)
+		clock.Advance(d)
+		time.Sleep(20 * time.Millisecond)
+	}
+	testing2.ExpectEqual(t, atomic.LoadInt32(&limited), int32(2))
+	testing2.ExpectEqual(t, atomic.LoadInt32(&maxRuns), int32(3))
+	testing2.ExpectEqual(t, len(s.Jobs()), 0) // never activate again
+}
+
+func BenchmarkSchedule(b *testing.B) {
+	clock := testing2.NewFakeClock(time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC))
+	s := NewScheduler(WithClock(clock))
+	s.Start()
+	defer s.Stop()
+
+	expr := cycle.NewExpression(time.Hour)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Schedule(func() {}, expr)
+	}
+}
+
+func BenchmarkDispatch(b *testing.B) {
+	for _, n := range []int{100, 10000} {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			start := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+			s := NewScheduler(WithClock(testing2.NewFakeClock(start)))
+			for i := 0; i < n; i++ {
+				s.Schedule(func() {}, cycle.NewExpression(time.Duration(i%3600+1)*time.Second))
+			}
+			s.mutex.Lock()
+			for _, job := range s.jobs {
+				s.prepare(job, start)
+			}
+			heap.Init(&s.jobs)
+			s.mutex.Unlock()
+
+			b.ResetTimer()
+			now := start
+			for i := 0; i < b.N; i++ {
+				now = now.Add(time.Second)
+				s.mutex.Lock()
+				s.dispatch(now)
+				s.mutex.Unlock()
+			}
+		})
+	}
+}
+
+func TestSchedulerOnce(t *testing.T) {
+	clock := testing2.NewFakeClock(time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC))
+	s := NewScheduler(WithClock(clock))
+	s.Start()
+	defer s.Stop()
+
+	activations := make(chan time.Time, 2)
+	fn := func() {
+		activations <- clock.Now()
+	}
+	name, err := s.ScheduleOnce(fn, 15*time.Minute)
+	testing2.AssertEqual(t, err, nil)
+	cancelled, err := s.ScheduleOnce(fn, 10*time.Minute, WithName("cancelled"))
+	testing2.AssertEqual(t, err, nil)
+	testing2.ExpectEqual(t, cancelled, "cancelled")
+	testing2.ExpectEqual(t, s.Cancel(cancelled), true)
+
+	clock.BlockUntil(1)
+	clock.Advance(15 * time.Minute)
+	have, ok := receive(activations, time.Second)
+	testing2.AssertEqual(t, ok, true)
+	testing2.ExpectEqual(t, have, clock.Now())
+
+	// removed after activated
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+	_, ok = receive(activations, 50*time.Millisecond)
+	testing2.ExpectEqual(t, ok, false)
+	testing2.ExpectEqual(t, s.Cancel(name), false)
+}
+
+func TestSchedulerOncePause(t *testing.T) {
+	clock := testing2.NewFakeClock(time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC))
+	st := &store{
+		records: make(map[string]JobRecord),
+	}
+	s := NewScheduler(WithClock(clock), WithStore(st))
+	activations := make(chan time.Time, 1)
+	s.Register("remind", func() {
+		activations <- clock.Now()
+	})
+	s.Start()
+	defer s.Stop()
+
+	name, err := s.ScheduleOnce(nil, 10*time.Minute, WithHandler("remind"))
+	testing2.AssertEqual(t, err, nil)
+	testing2.ExpectEqual(t, s.Pause(name), true)
+
+	// due while paused
+	clock.BlockUntil(1)
+	clock.Advance(10 * time.Minute)
+	clock.BlockUntil(1) // the timer is re-armed after dispatching
+	_, ok := receive(activations, 50*time.Millisecond)
+	testing2.ExpectEqual(t, ok, false)
+	jobs := s.Jobs()
+	testing2.AssertEqual(t, len(jobs), 1)
+	testing2.ExpectEqual(t, jobs[0].Paused, true)
+	record, _ := st.Get(name)
+	testing2.ExpectNotEqual(t, record, (*JobRecord)(nil))
+
+	// runs when it resumes
+	clock.Advance(time.Minute)
+	clock.BlockUntil(1)
+	testing2.ExpectEqual(t, s.Resume(name), true)
+	have, ok := receive(activations, time.Second)
+	testing2.AssertEqual(t, ok, true)
+	testing2.ExpectEqual(t, have, clock.Now())
+	time.Sleep(50 * time.Millisecond)
+	record, _ = st.Get(name)
+	testing2.ExpectEqual(t, record, (*JobRecord)(nil))
+	testing2.ExpectEqual(t, s.Cancel(name), false)
+}
+
+func TestSchedulerOnceRestore(t *testing.T) {
+	start := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+	clock := testing2.NewFakeClock(start)
+	st := &store{
+		records: make(map[string]JobRecord),
+	}
+
+	s := NewScheduler(WithClock(clock), WithStore(st))
+	s.Register("remind", func() {})
+	_, err := s.ScheduleAt(nil, start.Add(time.Hour), WithName("reminder"), WithHandler("remind"))
+	testing2.AssertEqual(t, err, nil)
+	_, err = s.ScheduleAt(nil, start.Add(time.Hour), WithHandler("unknown"))
+	testing2.ExpectNotEqual(t, err, nil)
+	testing2.ExpectEqual(t, len(st.records), 1)
+
+	// restarts after the job was due
+	clock.Advance(2 * time.Hour)
+	activations := make(chan time.Time, 1)
+	s = NewScheduler(WithClock(clock), WithStore(st))
+	s.Register("remind", func() {
+		activations <- clock.Now()
+	})
+	s.Start()
+	defer s.Stop()
+
+	_, ok := receive(activations, time.Second)
+	testing2.AssertEqual(t, ok, true)
+	time.Sleep(50 * time.Millisecond)
+	record, _ := st.Get("reminder")
+	testing2.ExpectEqual(t, record, (*JobRecord)(nil))
+}
+
+func TestSchedulerOnceInterrupted(t *testing.T) {
+	start := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+	clock := testing2.NewFakeClock(start.Add(2 * time.Hour))
+	st := &store{
+		records: map[string]JobRecord{
+			// activated but not completed before the process crashed
+			"reminder": {Name: "reminder", Handler: "remind", At: start.Add(time.Hour), Previous: start.Add(time.Hour)},
+			"function": {Name: "function", At: start.Add(time.Hour), Previous: start.Add(time.Hour)},
+		},
+	}
+
+	activations := make(chan time.Time, 1)
+	s := NewScheduler(WithClock(clock), WithStore(st), WithLogger(log.NewLogger(ioutil.Discard, "")))
+	s.Register("remind", func() {
+		activations <- clock.Now()
+	})
+	s.Start()
+	defer s.Stop()
+
+	_, ok := receive(activations, time.Second)
+	testing2.AssertEqual(t, ok, true)
+	time.Sleep(50 * time.Millisecond)
+	records, _ := st.List()
+	testing2.ExpectEqual(t, len(records), 0)
+}
@@ -0,0 +1,90 @@
+package jobs
+
+import (
+	"time"
+)
+
+// JobRecord represents the persistent state of a named job.
+type JobRecord struct {
+	Name     string
+	Previous time.Time // last activated time
+	Handler  string    // name of registered handler of one-off job
+	At       time.Time // activated time of one-off job, zero for recurring job
+}
+
+// JobStore represents a store where persist the state of jobs.
+// Only the named jobs are persisted.
+type JobStore interface {
+	// Get returns the record by given job name.
+	// It returns nil if record not found.
+	Get(name string) (*JobRecord, error)
+
+	// Save inserts/updates the record.
+	Save(record *JobRecord) error
+
+	// Remove removes the record by given job name.
+	Remove(name string) error
+
+	// List returns all records.
+	List() ([]*JobRecord, error)
+}
+
+// MisfirePolicy represents the policy for the activations missed while the scheduler was down.
+type MisfirePolicy byte
+
+// Misfire Policies.
+const (
+	// MisfireSkip skips all missed activations.
+	MisfireSkip MisfirePolicy = iota
+
+	// MisfireRunOnce runs the job once for the latest missed activation if there are any missed activations.
+	MisfireRunOnce
+
+	// MisfireRunAll runs the job once for each missed activation, at most MaxMisfires latest ones.
+	MisfireRunAll
+)
+
+// String returns the name of policy.
+func (p MisfirePolicy) String() string {
+	switch p {
+	case MisfireSkip:
+		return "Skip"
+	case MisfireRunOnce:
+		return "RunOnce"
+	case MisfireRunAll:
+		return "RunAll"
+	default:
+		return "Unknown"
+	}
+}
+
+// MaxMisfires is the maximum number of missed activations run by MisfireRunAll, the earlier ones are skipped.
+const MaxMisfires = 1000
+
+// misfires returns the activated times of expression in (from, to] run by the misfire policy of job and the number of skipped ones:
+// the latest one for MisfireRunOnce, or the latest MaxMisfires ones for MisfireRunAll.
+// The activations are walked one by one, only the returned ones are kept.
+func misfires(job *job, from, to time.Time) ([]time.Time, int) {
+	size := 1
+	if job.MisfirePolicy == MisfireRunAll {
+		size = MaxMisfires
+	}
+
+	ring := make([]time.Time, 0, size)
+	count := 0
+	for next := job.Expression.Next(from); !next.IsZero() && !next.After(to); next = job.Expression.Next(next) {
+		if len(ring) < size {
+			ring = append(ring, next)
+		} else {
+			ring[count%size] = next
+		}
+		count++
+	}
+	if count <= size {
+		return ring, 0
+	}
+
+	// rotates the ring to chronological order
+	i := count % size
+	return append(ring[i:], ring[:i]...), count - size
+}
@@ -0,0 +1,6 @@
+/*
+
+Package file providers a job store persisting the state of jobs in a JSON file.
+
+*/
+package file
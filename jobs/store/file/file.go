@@ -0,0 +1,142 @@
+package file
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/wayn3h0/gop/errors"
+	"github.com/wayn3h0/gop/jobs"
+)
+
+type store struct {
+	Locker  sync.Mutex
+	Path    string
+	Records map[string]jobs.JobRecord
+}
+
+// load reads the records from file.
+func (s *store) load() error {
+	data, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return errors.Wrapf(err, "jobs: could not read job store file %q", s.Path)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	err = json.Unmarshal(data, &s.Records)
+	if err != nil {
+		return errors.Wrapf(err, "jobs: could not unmarshal job store file %q", s.Path)
+	}
+
+	return nil
+}
+
+// flush writes the records to file.
+// It writes a temporary file and renames it for avoiding partial writes.
+func (s *store) flush() error {
+	data, err := json.MarshalIndent(s.Records, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "jobs: could not marshal job records to JSON data")
+	}
+
+	temp, err := ioutil.TempFile(filepath.Dir(s.Path), filepath.Base(s.Path)+".*")
+	if err != nil {
+		return errors.Wrapf(err, "jobs: could not create temporary file for job store file %q", s.Path)
+	}
+	defer os.Remove(temp.Name())
+
+	_, err = temp.Write(data)
+	if err == nil {
+		err = temp.Sync()
+	}
+	if e := temp.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return errors.Wrapf(err, "jobs: could not write job store file %q", s.Path)
+	}
+
+	err = os.Rename(temp.Name(), s.Path)
+	if err != nil {
+		return errors.Wrapf(err, "jobs: could not replace job store file %q", s.Path)
+	}
+
+	return nil
+}
+
+func (s *store) Get(name string) (*jobs.JobRecord, error) {
+	s.Locker.Lock()
+	defer s.Locker.Unlock()
+
+	if record, ok := s.Records[name]; ok {
+		return &record, nil
+	}
+
+	return nil, nil
+}
+
+func (s *store) Save(record *jobs.JobRecord) error {
+	s.Locker.Lock()
+	defer s.Locker.Unlock()
+
+	s.Records[record.Name] = *record
+
+	return s.flush()
+}
+
+func (s *store) Remove(name string) error {
+	s.Locker.Lock()
+	defer s.Locker.Unlock()
+
+	if _, ok := s.Records[name]; !ok {
+		return nil
+	}
+	delete(s.Records, name)
+
+	return s.flush()
+}
+
+func (s *store) List() ([]*jobs.JobRecord, error) {
+	s.Locker.Lock()
+	defer s.Locker.Unlock()
+
+	var records []*jobs.JobRecord
+	for _, record := range s.Records {
+		record := record
+		records = append(records, &record)
+	}
+
+	return records, nil
+}
+
+// NewStore returns a new job store persisting the records in given file.
+// The file will be created on first save if it does not exist.
+func NewStore(path string) (jobs.JobStore, error) {
+	if len(path) == 0 {
+		return nil, errors.New("jobs: path of job store file cannot be empty")
+	}
+
+	s := &store{
+		Path:    path,
+		Records: make(map[string]jobs.JobRecord),
+	}
+	err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// New is short to NewStore func.
+func New(path string) (jobs.JobStore, error) {
+	return NewStore(path)
+}
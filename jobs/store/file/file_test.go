@@ -0,0 +1,41 @@
+package file
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wayn3h0/gop/jobs"
+	testing2 "github.com/wayn3h0/gop/testing"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	previous := time.Date(2018, 10, 1, 2, 0, 0, 0, time.UTC)
+
+	s, err := NewStore(path)
+	testing2.AssertEqual(t, err, nil)
+
+	record, err := s.Get("backup")
+	testing2.AssertEqual(t, err, nil)
+	testing2.AssertEqual(t, record, (*jobs.JobRecord)(nil))
+
+	err = s.Save(&jobs.JobRecord{Name: "backup", Previous: previous})
+	testing2.AssertEqual(t, err, nil)
+
+	// reopen
+	s, err = NewStore(path)
+	testing2.AssertEqual(t, err, nil)
+	record, err = s.Get("backup")
+	testing2.AssertEqual(t, err, nil)
+	testing2.ExpectEqual(t, record.Previous.Equal(previous), true)
+	records, err := s.List()
+	testing2.AssertEqual(t, err, nil)
+	testing2.ExpectEqual(t, len(records), 1)
+
+	err = s.Remove("backup")
+	testing2.AssertEqual(t, err, nil)
+	s, _ = NewStore(path)
+	record, _ = s.Get("backup")
+	testing2.ExpectEqual(t, record, (*jobs.JobRecord)(nil))
+}
@@ -0,0 +1,6 @@
+/*
+
+Package memory providers an in-memory job store (the state is lost when the process exits).
+
+*/
+package memory
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"sync"
+
+	"github.com/wayn3h0/gop/jobs"
+)
+
+type store struct {
+	Locker  sync.RWMutex
+	Records map[string]jobs.JobRecord
+}
+
+func (s *store) Get(name string) (*jobs.JobRecord, error) {
+	s.Locker.RLock()
+	defer s.Locker.RUnlock()
+
+	if record, ok := s.Records[name]; ok {
+		return &record, nil
+	}
+
+	return nil, nil
+}
+
+func (s *store) Save(record *jobs.JobRecord) error {
+	s.Locker.Lock()
+	defer s.Locker.Unlock()
+
+	s.Records[record.Name] = *record
+
+	return nil
+}
+
+func (s *store) Remove(name string) error {
+	s.Locker.Lock()
+	defer s.Locker.Unlock()
+
+	delete(s.Records, name)
+
+	return nil
+}
+
+func (s *store) List() ([]*jobs.JobRecord, error) {
+	s.Locker.RLock()
+	defer s.Locker.RUnlock()
+
+	var records []*jobs.JobRecord
+	for _, record := range s.Records {
+		record := record
+		records = append(records, &record)
+	}
+
+	return records, nil
+}
+
+// NewStore returns a new in-memory job store.
+func NewStore() jobs.JobStore {
+	return &store{
+		Records: make(map[string]jobs.JobRecord),
+	}
+}
+
+// New is short to NewStore func.
+func New() jobs.JobStore {
+	return NewStore()
+}
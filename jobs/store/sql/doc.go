@@ -0,0 +1,6 @@
+/*
+
+Package sql providers a job store persisting the state of jobs in a SQL database table.
+
+*/
+package sql
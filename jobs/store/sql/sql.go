@@ -0,0 +1,177 @@
+package sql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wayn3h0/gop/errors"
+	"github.com/wayn3h0/gop/jobs"
+	sql2 "github.com/wayn3h0/gop/sql"
+)
+
+// DefaultTable is the default name of table for storing job records.
+const DefaultTable = "jobs"
+
+type store struct {
+	Database    sql2.Database
+	Table       string
+	Placeholder sql2.Placeholder
+}
+
+// statement returns the statement with table name and bind variables in style of database.
+func (s *store) statement(format string) string {
+	return s.Placeholder.Rebind(fmt.Sprintf(format, s.Table))
+}
+
+// initialize creates the table if it does not exist.
+// The columns of one-off jobs are added to the table created by earlier versions.
+func (s *store) initialize() error {
+	_, err := s.Database.Execute(s.statement("CREATE TABLE IF NOT EXISTS %s (name VARCHAR(255) NOT NULL PRIMARY KEY, previous BIGINT NOT NULL, handler VARCHAR(255) NOT NULL DEFAULT '', once_at BIGINT NOT NULL DEFAULT 0)"))
+	if err != nil {
+		return errors.Wrapf(err, "jobs: could not create table %q for job store", s.Table)
+	}
+
+	rows, err := s.Database.Query(s.statement("SELECT handler, once_at FROM %s WHERE 1 = 0"))
+	if err == nil {
+		sql2.CloseRows(rows)
+		return nil
+	}
+	for _, column := range []string{"handler VARCHAR(255) NOT NULL DEFAULT ''", "once_at BIGINT NOT NULL DEFAULT 0"} {
+		_, err = s.Database.Execute(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", s.Table, column))
+		if err != nil {
+			return errors.Wrapf(err, "jobs: could not add column to table %q for job store", s.Table)
+		}
+	}
+
+	return nil
+}
+
+func (s *store) Get(name string) (*jobs.JobRecord, error) {
+	rows, err := s.Database.Query(s.statement("SELECT previous, handler, once_at FROM %s WHERE name = ?"), name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "jobs: could not query record of job %q", name)
+	}
+	defer sql2.CloseRows(rows)
+
+	if !rows.Next() {
+		return nil, nil
+	}
+
+	var previous, at int64
+	var handler string
+	err = rows.Scan(&previous, &handler, &at)
+	if err != nil {
+		return nil, errors.Wrapf(err, "jobs: could not scan record of job %q", name)
+	}
+
+	return &jobs.JobRecord{
+		Name:     name,
+		Previous: fromUnixNano(previous),
+		Handler:  handler,
+		At:       fromUnixNano(at),
+	}, nil
+}
+
+// Save inserts/updates the record.
+// It deletes and inserts the record in a transaction, which works for all databases.
+func (s *store) Save(record *jobs.JobRecord) error {
+	tx, err := s.Database.Begin()
+	if err != nil {
+		return errors.Wrapf(err, "jobs: could not save record of job %q", record.Name)
+	}
+
+	_, err = tx.Execute(s.statement("DELETE FROM %s WHERE name = ?"), record.Name)
+	if err == nil {
+		_, err = tx.Execute(s.statement("INSERT INTO %s (name, previous, handler, once_at) VALUES (?, ?, ?, ?)"), record.Name, toUnixNano(record.Previous), record.Handler, toUnixNano(record.At))
+	}
+	if err != nil {
+		tx.Rollback()
+		return errors.Wrapf(err, "jobs: could not save record of job %q", record.Name)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.Wrapf(err, "jobs: could not save record of job %q", record.Name)
+	}
+
+	return nil
+}
+
+func (s *store) Remove(name string) error {
+	_, err := s.Database.Execute(s.statement("DELETE FROM %s WHERE name = ?"), name)
+	if err != nil {
+		return errors.Wrapf(err, "jobs: could not remove record of job %q", name)
+	}
+
+	return nil
+}
+
+func (s *store) List() ([]*jobs.JobRecord, error) {
+	rows, err := s.Database.Query(s.statement("SELECT name, previous, handler, once_at FROM %s"))
+	if err != nil {
+		return nil, errors.Wrap(err, "jobs: could not query records of jobs")
+	}
+	defer sql2.CloseRows(rows)
+
+	var records []*jobs.JobRecord
+	for rows.Next() {
+		var name, handler string
+		var previous, at int64
+		err = rows.Scan(&name, &previous, &handler, &at)
+		if err != nil {
+			return nil, errors.Wrap(err, "jobs: could not scan records of jobs")
+		}
+		records = append(records, &jobs.JobRecord{
+			Name:     name,
+			Previous: fromUnixNano(previous),
+			Handler:  handler,
+			At:       fromUnixNano(at),
+		})
+	}
+
+	return records, nil
+}
+
+func toUnixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano()
+}
+
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, n)
+}
+
+// NewStore returns a new job store persisting the records in given table (DefaultTable if empty).
+// The table will be created if it does not exist.
+func NewStore(db sql2.Database, table string, placeholder sql2.Placeholder) (jobs.JobStore, error) {
+	if db == nil {
+		return nil, errors.New("jobs: database of job store cannot be nil")
+	}
+	if len(table) == 0 {
+		table = DefaultTable
+	}
+
+	s := &store{
+		Database:    db,
+		Table:       table,
+		Placeholder: placeholder,
+	}
+	err := s.initialize()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// New is short to NewStore func.
+func New(db sql2.Database, table string, placeholder sql2.Placeholder) (jobs.JobStore, error) {
+	return NewStore(db, table, placeholder)
+}
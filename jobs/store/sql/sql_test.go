@@ -0,0 +1,70 @@
+package sql
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wayn3h0/gop/jobs"
+	sql2 "github.com/wayn3h0/gop/sql"
+	"github.com/wayn3h0/gop/sql/sqlite"
+	testing2 "github.com/wayn3h0/gop/testing"
+)
+
+func TestStore(t *testing.T) {
+	db, err := sqlite.NewDatabase(filepath.Join(t.TempDir(), "jobs.db"))
+	testing2.AssertEqual(t, err, nil)
+	previous := time.Date(2018, 10, 1, 2, 0, 0, 0, time.UTC)
+
+	s, err := NewStore(db, "", sql2.PlaceholderQuestion)
+	testing2.AssertEqual(t, err, nil)
+
+	record, err := s.Get("backup")
+	testing2.AssertEqual(t, err, nil)
+	testing2.AssertEqual(t, record, (*jobs.JobRecord)(nil))
+
+	for i := 0; i < 2; i++ { // insert & update
+		previous = previous.Add(time.Hour)
+		err = s.Save(&jobs.JobRecord{Name: "backup", Previous: previous})
+		testing2.AssertEqual(t, err, nil)
+
+		record, err = s.Get("backup")
+		testing2.AssertEqual(t, err, nil)
+		testing2.ExpectEqualL(t, record.Previous.Equal(previous), true, i)
+	}
+
+	at := previous.Add(time.Hour)
+	err = s.Save(&jobs.JobRecord{Name: "reminder", Handler: "remind", At: at})
+	testing2.AssertEqual(t, err, nil)
+	records, err := s.List()
+	testing2.AssertEqual(t, err, nil)
+	testing2.AssertEqual(t, len(records), 2)
+	for _, record := range records {
+		if record.Name == "reminder" {
+			testing2.ExpectEqual(t, record.Handler, "remind")
+			testing2.ExpectEqual(t, record.At.Equal(at), true)
+			testing2.ExpectEqual(t, record.Previous.IsZero(), true)
+		}
+	}
+
+	err = s.Remove("backup")
+	testing2.AssertEqual(t, err, nil)
+	record, _ = s.Get("backup")
+	testing2.ExpectEqual(t, record, (*jobs.JobRecord)(nil))
+}
+
+func TestStoreUpgrade(t *testing.T) {
+	db, err := sqlite.NewDatabase(filepath.Join(t.TempDir(), "jobs.db"))
+	testing2.AssertEqual(t, err, nil)
+	_, err = db.Execute("CREATE TABLE jobs (name VARCHAR(255) NOT NULL PRIMARY KEY, previous BIGINT NOT NULL)")
+	testing2.AssertEqual(t, err, nil)
+	_, err = db.Execute("INSERT INTO jobs (name, previous) VALUES ('backup', 0)")
+	testing2.AssertEqual(t, err, nil)
+
+	s, err := NewStore(db, "", sql2.PlaceholderQuestion)
+	testing2.AssertEqual(t, err, nil)
+	record, err := s.Get("backup")
+	testing2.AssertEqual(t, err, nil)
+	testing2.ExpectEqual(t, record.Handler, "")
+	testing2.ExpectEqual(t, record.At.IsZero(), true)
+}
@@ -0,0 +1,351 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/wayn3h0/gop/clock"
+	"github.com/wayn3h0/gop/errors"
+	"github.com/wayn3h0/gop/jobs/expression"
+)
+
+// FailurePolicy represents the policy of workflow when a step fails.
+type FailurePolicy byte
+
+// Failure Policies.
+const (
+	// FailStop stops starting the pending steps, the running steps are not interrupted.
+	FailStop FailurePolicy = iota
+
+	// FailContinue skips the steps depend on the failed step and continues the others.
+	FailContinue
+
+	// FailCompensate stops as FailStop and runs the compensations of succeeded steps in reverse order.
+	FailCompensate
+)
+
+// String returns the name of policy.
+func (p FailurePolicy) String() string {
+	switch p {
+	case FailStop:
+		return "Stop"
+	case FailContinue:
+		return "Continue"
+	case FailCompensate:
+		return "Compensate"
+	default:
+		return "Unknown"
+	}
+}
+
+// StepStatus represents the status of workflow step.
+type StepStatus byte
+
+// Step Statuses.
+const (
+	StepPending StepStatus = iota
+	StepRunning
+	StepSucceeded
+	StepFailed
+	StepSkipped
+	StepCompensated
+)
+
+// String returns the name of status.
+func (s StepStatus) String() string {
+	switch s {
+	case StepPending:
+		return "Pending"
+	case StepRunning:
+		return "Running"
+	case StepSucceeded:
+		return "Succeeded"
+	case StepFailed:
+		return "Failed"
+	case StepSkipped:
+		return "Skipped"
+	case StepCompensated:
+		return "Compensated"
+	default:
+		return "Unknown"
+	}
+}
+
+// StepExecution represents an execution of workflow step.
+type StepExecution struct {
+	Name     string
+	Status   StepStatus
+	Started  time.Time
+	Finished time.Time
+	Error    error // error of step, or of compensation if the step is not compensated
+}
+
+// step represents a step of workflow.
+type step struct {
+	Name         string
+	Function     func() error
+	Dependencies []string
+	Compensation func()
+}
+
+// Workflow represents a directed acyclic graph of steps.
+// A step runs after all its dependencies succeeded, the independent steps run concurrently.
+type Workflow struct {
+	name   string
+	policy FailurePolicy
+	steps  []*step
+	err    error
+}
+
+// find returns the step by name.
+func (w *Workflow) find(name string) *step {
+	for _, s := range w.steps {
+		if s.Name == name {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// Name returns the name of workflow.
+func (w *Workflow) Name() string {
+	return w.name
+}
+
+// Step adds a step runs after the dependencies succeeded.
+func (w *Workflow) Step(name string, fn func() error, dependencies ...string) *Workflow {
+	switch {
+	case w.err != nil:
+	case fn == nil:
+		w.err = errors.Newf("jobs: function of step %q cannot be nil", name)
+	case w.find(name) != nil:
+		w.err = errors.Newf("jobs: step %q is duplicated", name)
+	default:
+		w.steps = append(w.steps, &step{
+			Name:         name,
+			Function:     fn,
+			Dependencies: dependencies,
+		})
+	}
+
+	return w
+}
+
+// Compensate sets the compensation of step, it runs if the step succeeded and the workflow failed with FailCompensate.
+func (w *Workflow) Compensate(name string, fn func()) *Workflow {
+	if s := w.find(name); s != nil {
+		s.Compensation = fn
+	} else if w.err == nil {
+		w.err = errors.Newf("jobs: step %q of compensation is not found", name)
+	}
+
+	return w
+}
+
+// Validate checks the steps, the dependencies must exist and have no cycle.
+func (w *Workflow) Validate() error {
+	if w.err != nil {
+		return w.err
+	}
+
+	remaining := make(map[string]int)
+	for _, s := range w.steps {
+		for _, dependency := range s.Dependencies {
+			if w.find(dependency) == nil {
+				return errors.Newf("jobs: dependency %q of step %q is not found", dependency, s.Name)
+			}
+		}
+		remaining[s.Name] = len(s.Dependencies)
+	}
+
+	// removes the steps without dependencies until no one left
+	for n := 0; n < len(w.steps); n++ {
+		var next *step
+		for _, s := range w.steps {
+			if count, ok := remaining[s.Name]; ok && count == 0 {
+				next = s
+				break
+			}
+		}
+		if next == nil {
+			return errors.Newf("jobs: steps of workflow %q have a cycle", w.name)
+		}
+		delete(remaining, next.Name)
+		for _, s := range w.steps {
+			for _, dependency := range s.Dependencies {
+				if dependency == next.Name {
+					remaining[s.Name]--
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// result represents the result of step.
+type result struct {
+	Name     string
+	Finished time.Time
+	Error    error
+}
+
+// call calls the function of step, it converts the panic to error.
+func (s *step) call() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Newf("jobs: step %q panicked: %v", s.Name, r)
+		}
+	}()
+
+	return s.Function()
+}
+
+// compensate calls the compensation of step, it converts the panic to error.
+func (s *step) compensate() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Newf("jobs: compensation of step %q panicked: %v", s.Name, r)
+		}
+	}()
+
+	s.Compensation()
+
+	return nil
+}
+
+// Run runs the workflow and returns the executions of steps in the order they were added.
+// It returns the first error of steps, or the error of validation.
+func (w *Workflow) Run() ([]StepExecution, error) {
+	return w.run(clock.Default)
+}
+
+// run runs the workflow, the started and finished times of steps are read from the clock.
+func (w *Workflow) run(clock clock.Clock) ([]StepExecution, error) {
+	err := w.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	executions := make(map[string]*StepExecution)
+	remaining := make(map[string]int)
+	blocked := make(map[string]bool)
+	for _, s := range w.steps {
+		executions[s.Name] = &StepExecution{
+			Name:   s.Name,
+			Status: StepPending,
+		}
+		remaining[s.Name] = len(s.Dependencies)
+	}
+
+	results := make(chan result)
+	running := 0
+	start := func(s *step) {
+		running++
+		executions[s.Name].Status = StepRunning
+		executions[s.Name].Started = clock.Now()
+		go func() {
+			err := s.call()
+			results <- result{Name: s.Name, Finished: clock.Now(), Error: err}
+		}()
+	}
+
+	var first error
+	var succeeded []*step
+	var finish func(name string)
+	finish = func(name string) { // starts or skips the dependents of finished step
+		for _, s := range w.steps {
+			for _, dependency := range s.Dependencies {
+				if dependency != name {
+					continue
+				}
+				remaining[s.Name]--
+				if executions[name].Status != StepSucceeded {
+					blocked[s.Name] = true
+				}
+				if remaining[s.Name] > 0 {
+					continue
+				}
+				if blocked[s.Name] || (first != nil && w.policy != FailContinue) {
+					executions[s.Name].Status = StepSkipped
+					finish(s.Name)
+				} else {
+					start(s)
+				}
+			}
+		}
+	}
+
+	for _, s := range w.steps {
+		if len(s.Dependencies) == 0 {
+			start(s)
+		}
+	}
+	for running > 0 {
+		r := <-results
+		running--
+
+		execution := executions[r.Name]
+		execution.Finished = r.Finished
+		execution.Error = r.Error
+		if r.Error != nil {
+			execution.Status = StepFailed
+			if first == nil {
+				first = r.Error
+			}
+		} else {
+			execution.Status = StepSucceeded
+			succeeded = append(succeeded, w.find(r.Name))
+		}
+		finish(r.Name)
+	}
+
+	if first != nil && w.policy == FailCompensate {
+		for i := len(succeeded) - 1; i >= 0; i-- {
+			if s := succeeded[i]; s.Compensation != nil {
+				err := s.compensate()
+				if err != nil {
+					executions[s.Name].Error = err
+					continue
+				}
+				executions[s.Name].Status = StepCompensated
+			}
+		}
+	}
+
+	list := make([]StepExecution, 0, len(w.steps))
+	for _, s := range w.steps {
+		list = append(list, *executions[s.Name])
+	}
+
+	return list, first
+}
+
+// NewWorkflow returns a new workflow with failure policy.
+func NewWorkflow(name string, policy FailurePolicy) *Workflow {
+	return &Workflow{
+		name:   name,
+		policy: policy,
+	}
+}
+
+// ScheduleWorkflow adds the workflow as a job triggered by the expression, the name of workflow is the name of job by default.
+// Each run of workflow is recorded as an execution in history.
+func (s *Scheduler) ScheduleWorkflow(w *Workflow, expr expression.Expression, options ...JobOption) error {
+	err := w.Validate()
+	if err != nil {
+		return err
+	}
+
+	job := &job{
+		Expression: expr,
+		Name:       w.name,
+		Workflow:   w,
+	}
+	for _, option := range options {
+		option(job)
+	}
+	s.add(job)
+
+	return nil
+}
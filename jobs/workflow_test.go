@@ -0,0 +1,169 @@
+package jobs
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wayn3h0/gop/errors"
+	"github.com/wayn3h0/gop/jobs/expression/cron"
+	"github.com/wayn3h0/gop/jobs/expression/cycle"
+	testing2 "github.com/wayn3h0/gop/testing"
+)
+
+// statuses returns the statuses of steps.
+func statuses(executions []StepExecution) map[string]StepStatus {
+	m := make(map[string]StepStatus)
+	for _, e := range executions {
+		m[e.Name] = e.Status
+	}
+
+	return m
+}
+
+func TestWorkflow(t *testing.T) {
+	var mutex sync.Mutex
+	var order []string
+	step := func(name string) func() error {
+		return func() error {
+			mutex.Lock()
+			order = append(order, name)
+			mutex.Unlock()
+			return nil
+		}
+	}
+
+	// fan-out and fan-in
+	w := NewWorkflow("etl", FailStop).
+		Step("extract", step("extract")).
+		Step("orders", step("orders"), "extract").
+		Step("users", step("users"), "extract").
+		Step("load", step("load"), "orders", "users")
+	executions, err := w.Run()
+	testing2.AssertEqual(t, err, nil)
+	testing2.AssertEqual(t, len(executions), 4)
+	for i, e := range executions {
+		testing2.ExpectEqualL(t, e.Status, StepSucceeded, i)
+	}
+	testing2.AssertEqual(t, len(order), 4)
+	testing2.ExpectEqual(t, order[0], "extract")
+	testing2.ExpectEqual(t, order[3], "load")
+}
+
+func TestWorkflowFailure(t *testing.T) {
+	failure := errors.New("failure")
+	succeed := func() error { return nil }
+	fail := func() error { return failure }
+
+	// stop
+	executions, err := NewWorkflow("stop", FailStop).
+		Step("a", fail).
+		Step("b", succeed, "a").
+		Run()
+	testing2.ExpectEqual(t, err, failure)
+	testing2.ExpectEqual(t, statuses(executions), map[string]StepStatus{"a": StepFailed, "b": StepSkipped})
+
+	// continue
+	executions, err = NewWorkflow("continue", FailContinue).
+		Step("a", fail).
+		Step("b", succeed, "a").
+		Step("c", succeed, "b").
+		Step("d", succeed).
+		Step("e", succeed, "d").
+		Run()
+	testing2.ExpectEqual(t, err, failure)
+	testing2.ExpectEqual(t, statuses(executions), map[string]StepStatus{"a": StepFailed, "b": StepSkipped, "c": StepSkipped, "d": StepSucceeded, "e": StepSucceeded})
+
+	// compensate
+	compensated := false
+	executions, err = NewWorkflow("compensate", FailCompensate).
+		Step("a", succeed).
+		Step("b", func() error { panic("boom") }, "a").
+		Step("c", succeed, "b").
+		Compensate("a", func() { compensated = true }).
+		Run()
+	testing2.ExpectNotEqual(t, err, nil)
+	testing2.ExpectEqual(t, compensated, true)
+	testing2.ExpectEqual(t, statuses(executions), map[string]StepStatus{"a": StepCompensated, "b": StepFailed, "c": StepSkipped})
+
+	// panicked compensation
+	compensated = false
+	executions, err = NewWorkflow("compensate", FailCompensate).
+		Step("a", succeed).
+		Step("b", succeed).
+		Step("c", fail, "a", "b").
+		Compensate("a", func() { compensated = true }).
+		Compensate("b", func() { panic("boom") }).
+		Run()
+	testing2.ExpectEqual(t, err, failure)
+	testing2.ExpectEqual(t, compensated, true)
+	testing2.ExpectEqual(t, statuses(executions), map[string]StepStatus{"a": StepCompensated, "b": StepSucceeded, "c": StepFailed})
+	testing2.ExpectNotEqual(t, executions[1].Error, nil)
+}
+
+func TestWorkflowValidate(t *testing.T) {
+	succeed := func() error { return nil }
+	workflows := []*Workflow{
+		NewWorkflow("cycle", FailStop).Step("a", succeed, "c").Step("b", succeed, "a").Step("c", succeed, "b"),
+		NewWorkflow("missing", FailStop).Step("a", succeed, "b"),
+		NewWorkflow("duplicated", FailStop).Step("a", succeed).Step("a", succeed),
+		NewWorkflow("compensation", FailStop).Compensate("a", func() {}),
+	}
+	for i, w := range workflows {
+		testing2.ExpectNotEqualL(t, w.Validate(), nil, i)
+	}
+
+	s := NewScheduler()
+	testing2.ExpectNotEqual(t, s.ScheduleWorkflow(workflows[0], cycle.NewExpression(time.Hour)), nil)
+}
+
+func TestSchedulerWorkflow(t *testing.T) {
+	clock := testing2.NewFakeClock(time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC))
+	s := NewScheduler(WithClock(clock))
+
+	done := make(chan time.Time, 1)
+	w := NewWorkflow("etl", FailStop).
+		Step("extract", func() error { return nil }).
+		Step("load", func() error {
+			done <- clock.Now()
+			return nil
+		}, "extract")
+	err := s.ScheduleWorkflow(w, cycle.NewExpression(time.Hour))
+	testing2.AssertEqual(t, err, nil)
+	s.Start()
+	defer s.Stop()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+	_, ok := receive(done, time.Second)
+	testing2.AssertEqual(t, ok, true)
+	time.Sleep(50 * time.Millisecond)
+
+	history := s.History("etl")
+	testing2.AssertEqual(t, len(history), 1)
+	testing2.ExpectEqual(t, history[0].Activation, clock.Now())
+	testing2.ExpectEqual(t, history[0].Error, nil)
+	testing2.ExpectEqual(t, statuses(history[0].Steps), map[string]StepStatus{"extract": StepSucceeded, "load": StepSucceeded})
+	for i, step := range history[0].Steps {
+		testing2.ExpectEqualL(t, step.Started, clock.Now(), i)
+		testing2.ExpectEqualL(t, step.Finished, clock.Now(), i)
+	}
+}
+
+func TestSchedulerWorkflowNamedExpression(t *testing.T) {
+	start := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+	s := NewScheduler(WithClock(testing2.NewFakeClock(start)))
+	e, err := cron.NewExpression("H H * * *")
+	testing2.AssertEqual(t, err, nil)
+	for _, name := range []string{"backup", "report"} {
+		w := NewWorkflow(name, FailStop).Step("a", func() error { return nil })
+		testing2.AssertEqual(t, s.ScheduleWorkflow(w, e), nil)
+	}
+	s.Start()
+	defer s.Stop()
+
+	for i, job := range s.Jobs() {
+		named, _ := cron.Parse("H H * * *", job.Name, nil)
+		testing2.ExpectEqualL(t, job.Next, named.Next(start), i)
+	}
+}
@@ -0,0 +1,47 @@
+package sql
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Placeholder represents the style of bind variables in SQL statement.
+type Placeholder byte
+
+// Placeholder Styles.
+const (
+	// PlaceholderQuestion represents the question mark style (MySQL, SQLite): ?
+	PlaceholderQuestion Placeholder = iota
+
+	// PlaceholderDollar represents the numbered dollar style (PostgreSQL): $1, $2, ...
+	PlaceholderDollar
+)
+
+// Rebind converts the question mark bind variables in statement to the style of placeholder.
+// Question marks inside quoted strings are left as is.
+func (p Placeholder) Rebind(statement string) string {
+	if p != PlaceholderDollar {
+		return statement
+	}
+
+	var buf strings.Builder
+	var quote rune
+	n := 0
+	for _, c := range statement {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			n++
+			buf.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		buf.WriteRune(c)
+	}
+
+	return buf.String()
+}
@@ -9,6 +9,23 @@ type Rows interface {
 	Scan(dest ...interface{}) error
 }
 
+// RowsCloser represents the rows can be closed before read to the end (e.g. the rows wrap *sql.Rows).
+type RowsCloser interface {
+	Rows
+
+	// Close closes the rows.
+	Close() error
+}
+
+// CloseRows closes the rows if it implements RowsCloser interface, it should be called if the rows are not read to the end.
+func CloseRows(rows Rows) error {
+	if closer, ok := rows.(RowsCloser); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
 // Transaction represents a SQL transaction.
 type Transaction interface {
 	// Execute executes the command and returns the number of rows affected in the transaction.
@@ -34,4 +51,7 @@ type Database interface {
 
 	// Begin starts a transaction.
 	Begin() (Transaction, error)
+
+	// Close closes the database and releases the connections.
+	Close() error
 }
@@ -0,0 +1,151 @@
+package testing
+
+import (
+	"sync"
+	"time"
+
+	"github.com/wayn3h0/gop/clock"
+)
+
+// fakeTimer represents a timer of fake clock.
+type fakeTimer struct {
+	clock    *FakeClock
+	channel  chan time.Time
+	deadline time.Time
+}
+
+// C implements clock.Timer interface.
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.channel
+}
+
+// Stop implements clock.Timer interface.
+func (t *fakeTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+
+	return t.clock.remove(t)
+}
+
+// Reset implements clock.Timer interface.
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+
+	active := t.clock.remove(t)
+	t.deadline = t.clock.now.Add(d)
+	t.clock.add(t)
+
+	return active
+}
+
+// FakeClock represents a fake clock advanced manually, it's useful for testing the components depend on clock.Clock.
+// The timers fire when the clock is advanced to or past their deadlines.
+type FakeClock struct {
+	mutex   sync.Mutex
+	changed *sync.Cond
+	now     time.Time
+	timers  []*fakeTimer
+}
+
+// add adds the timer or fires it if the deadline has passed, the mutex should be held.
+func (c *FakeClock) add(t *fakeTimer) {
+	if !t.deadline.After(c.now) {
+		select {
+		case t.channel <- c.now:
+		default:
+		}
+		return
+	}
+
+	c.timers = append(c.timers, t)
+	c.changed.Broadcast()
+}
+
+// remove removes the timer and reports whether it was waiting, the mutex should be held.
+func (c *FakeClock) remove(t *fakeTimer) bool {
+	for i, v := range c.timers {
+		if v == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			c.changed.Broadcast()
+			return true
+		}
+	}
+
+	return false
+}
+
+// Now implements clock.Clock interface.
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+// NewTimer implements clock.Clock interface.
+func (c *FakeClock) NewTimer(d time.Duration) clock.Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	t := &fakeTimer{
+		clock:    c,
+		channel:  make(chan time.Time, 1),
+		deadline: c.now.Add(d),
+	}
+	c.add(t)
+
+	return t
+}
+
+// After implements clock.Clock interface.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+// Set sets the current time and fires the timers whose deadlines have passed.
+func (c *FakeClock) Set(now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = now
+	timers := c.timers
+	c.timers = nil
+	for _, t := range timers {
+		c.add(t)
+	}
+	c.changed.Broadcast()
+}
+
+// Advance moves the current time forward by duration and fires the timers whose deadlines have passed.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Timers returns the number of waiting timers.
+func (c *FakeClock) Timers() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.timers)
+}
+
+// BlockUntil blocks until there are at least n waiting timers.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for len(c.timers) < n {
+		c.changed.Wait()
+	}
+}
+
+// NewFakeClock returns a new fake clock starts at given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{
+		now: now,
+	}
+	c.changed = sync.NewCond(&c.mutex)
+
+	return c
+}